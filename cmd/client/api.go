@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+)
+
+// apiClient выполняет запросы к сервису от имени пользователя из настроек.
+type apiClient struct {
+	base     *url.URL
+	settings *settings
+	jar      *fileJar
+	http     *http.Client
+}
+
+// newAPIClient создаёт клиент сервиса с cookie из jar. Редиректы не
+// выполняются: resolve показывает их, а не переходит по ним.
+func newAPIClient(s *settings, jar *fileJar) (*apiClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(s.Server, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("server: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("server: %q is not an http(s) URL", s.Server)
+	}
+	return &apiClient{
+		base:     base,
+		settings: s,
+		jar:      jar,
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: s.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// statusError — ответ сервиса с неожиданным кодом.
+type statusError struct {
+	method, path string
+	status       string
+	body         string
+}
+
+// Error возвращает описание ответа с текстом ошибки сервиса, если он есть.
+func (e *statusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("%s %s: %s", e.method, e.path, e.status)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.path, e.status, e.body)
+}
+
+// do выполняет запрос method к path с телом body в формате JSON (если оно
+// не nil) и возвращает ответ, если его код входит в expected. Изменяющие
+// запросы сопровождаются CSRF-токеном из cookie csrf_token: сервис проверяет
+// его у пользователей, определённых по cookie.
+func (c *apiClient) do(method, path string, body any, expected ...int) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base.String()+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.settings.APIKey != "" {
+		req.Header.Set(auth.APIKeyHeader, c.settings.APIKey)
+	}
+	if c.settings.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.settings.Token)
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		token, err := c.csrfToken()
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set(middleware.CSRFHeader, token)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return nil, &statusError{method: method, path: path, status: resp.Status, body: strings.TrimSpace(string(text))}
+}
+
+// csrfToken возвращает CSRF-токен для изменяющего запроса. Если клиент уже
+// известен сервису по cookie, а токена ещё нет, он запрашивается безопасным
+// запросом: без токена сервис отклонит изменение.
+func (c *apiClient) csrfToken() (string, error) {
+	if token, ok := c.jar.Cookie(c.base, middleware.CSRFCookieName); ok {
+		return token, nil
+	}
+	_, hasUser := c.jar.Cookie(c.base, auth.CookieName)
+	_, hasSession := c.jar.Cookie(c.base, auth.SessionCookieName)
+	if !hasUser && !hasSession {
+		// новый пользователь получит cookie вместе с ответом
+		return "", nil
+	}
+	resp, err := c.do(http.MethodGet, "/api/user/urls", nil, http.StatusOK, http.StatusNoContent, http.StatusUnauthorized)
+	if err != nil {
+		return "", fmt.Errorf("fetch CSRF token: %w", err)
+	}
+	resp.Body.Close()
+	if token, ok := c.jar.Cookie(c.base, middleware.CSRFCookieName); ok {
+		return token, nil
+	}
+	return resp.Header.Get(middleware.CSRFHeader), nil
+}
+
+// decode читает JSON-ответ в v и закрывает тело ответа.
+func decode(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+)
+
+// command — подкоманда клиента.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *apiClient, args []string, stdin io.Reader) (result, error)
+}
+
+// commands — подкоманды клиента в порядке вывода в справке.
+var commands = []command{
+	{"shorten", "URL...", "сократить ссылки", shorten},
+	{"batch", "[-f файл]", "сократить ссылки из файла или stdin, по одной в строке", batch},
+	{"list", "", "показать свои ссылки", list},
+	{"delete", "КЛЮЧ...", "удалить свои ссылки по ключу или короткому URL", deleteURLs},
+	{"resolve", "КЛЮЧ", "показать исходный URL короткой ссылки", resolve},
+	{"stats", "", "сводка по своим ссылкам", stats},
+	{"login", "[-claim]", "войти в учётную запись по email и паролю", login},
+	{"logout", "", "завершить сессию", logout},
+}
+
+// usageError — ошибка в аргументах команды; клиент завершается с кодом 2.
+type usageError struct {
+	msg string
+}
+
+// Error возвращает описание ошибки.
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// shortened — результат сокращения одной ссылки.
+type shortened struct {
+	OriginalURL string `json:"original_url"`
+	ShortURL    string `json:"short_url"`
+	// Existed — ссылка была сокращена раньше; ShortURL — существующая короткая ссылка.
+	Existed bool `json:"existed"`
+}
+
+// shorten сокращает каждую ссылку из args.
+func shorten(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) == 0 {
+		return result{}, usageError{"shorten: at least one URL is required"}
+	}
+	res := result{header: []string{"ORIGINAL URL", "SHORT URL", "STATUS"}}
+	var items []shortened
+	for _, originalURL := range args {
+		resp, err := c.do(http.MethodPost, "/api/shorten", models.ShortenRequest{URL: originalURL},
+			http.StatusCreated, http.StatusConflict)
+		if err != nil {
+			return result{}, err
+		}
+		var body models.ShortenResponse
+		if err := decode(resp, &body); err != nil {
+			return result{}, err
+		}
+		item := shortened{OriginalURL: originalURL, ShortURL: body.Result, Existed: resp.StatusCode == http.StatusConflict}
+		items = append(items, item)
+		status := "created"
+		if item.Existed {
+			status = "existed"
+		}
+		res.rows = append(res.rows, []string{item.OriginalURL, item.ShortURL, status})
+		res.plain = append(res.plain, item.ShortURL)
+	}
+	res.value = items
+	return res, nil
+}
+
+// batchItem — результат пакетного сокращения одной ссылки.
+type batchItem struct {
+	CorrelationID string `json:"correlation_id"`
+	OriginalURL   string `json:"original_url"`
+	ShortURL      string `json:"short_url"`
+}
+
+// batch сокращает ссылки из файла (по умолчанию из stdin) одним запросом.
+// Каждая непустая строка — URL или пара "идентификатор URL"; без
+// идентификатора им служит номер строки. Строки, начинающиеся с #, пропускаются.
+func batch(c *apiClient, args []string, stdin io.Reader) (result, error) {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("f", "-", `Файл со ссылками, "-" — stdin`)
+	if err := fs.Parse(args); err != nil {
+		return result{}, usageError{"batch: " + err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return result{}, usageError{"batch: unexpected arguments: " + strings.Join(fs.Args(), " ")}
+	}
+
+	input := stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return result{}, err
+		}
+		defer f.Close()
+		input = f
+	}
+	req, err := readBatch(input)
+	if err != nil {
+		return result{}, err
+	}
+
+	resp, err := c.do(http.MethodPost, "/api/shorten/batch", req, http.StatusCreated)
+	if err != nil {
+		return result{}, err
+	}
+	var body []models.URLBatchResponse
+	if err := decode(resp, &body); err != nil {
+		return result{}, err
+	}
+
+	originals := make(map[string]string, len(req))
+	for _, item := range req {
+		originals[item.CorrelationID] = item.OriginalURL
+	}
+	res := result{header: []string{"ID", "ORIGINAL URL", "SHORT URL"}}
+	items := make([]batchItem, 0, len(body))
+	for _, item := range body {
+		items = append(items, batchItem{CorrelationID: item.CorrelationID, OriginalURL: originals[item.CorrelationID], ShortURL: item.ShortURL})
+		res.rows = append(res.rows, []string{item.CorrelationID, originals[item.CorrelationID], item.ShortURL})
+		res.plain = append(res.plain, item.ShortURL)
+	}
+	res.value = items
+	return res, nil
+}
+
+// readBatch разбирает входные данные команды batch.
+func readBatch(r io.Reader) (models.ShortURLBatchRequest, error) {
+	var req models.ShortURLBatchRequest
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		item := models.URLBatchRequest{CorrelationID: strconv.Itoa(line), OriginalURL: text}
+		if fields := strings.Fields(text); len(fields) == 2 {
+			item.CorrelationID, item.OriginalURL = fields[0], fields[1]
+		} else if len(fields) > 2 {
+			return nil, fmt.Errorf("batch: line %d: expected URL or \"id URL\"", line)
+		}
+		req = append(req, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(req) == 0 {
+		return nil, errors.New("batch: no URLs in input")
+	}
+	return req, nil
+}
+
+// userURLs возвращает ссылки пользователя.
+func userURLs(c *apiClient) ([]models.UserURLsResponse, error) {
+	resp, err := c.do(http.MethodGet, "/api/user/urls", nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	urls := []models.UserURLsResponse{}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return urls, nil
+	}
+	return urls, decode(resp, &urls)
+}
+
+// urlStatus возвращает состояние ссылки: active, deleted или disabled.
+func urlStatus(u models.UserURLsResponse) string {
+	switch {
+	case u.DeletedFlag:
+		return "deleted"
+	case u.Disabled:
+		return "disabled"
+	default:
+		return "active"
+	}
+}
+
+// list выводит ссылки пользователя.
+func list(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) > 0 {
+		return result{}, usageError{"list: unexpected arguments: " + strings.Join(args, " ")}
+	}
+	urls, err := userURLs(c)
+	if err != nil {
+		return result{}, err
+	}
+	res := result{value: urls, header: []string{"SHORT URL", "ORIGINAL URL", "STATUS"}}
+	for _, u := range urls {
+		res.rows = append(res.rows, []string{u.ShortURL, u.OriginalURL, urlStatus(u)})
+		res.plain = append(res.plain, u.ShortURL)
+	}
+	return res, nil
+}
+
+// keyFromArg возвращает короткий ключ из аргумента: самого ключа или
+// полного короткого URL.
+func keyFromArg(arg string) string {
+	if u, err := url.Parse(arg); err == nil && u.Host != "" {
+		return path.Base(u.Path)
+	}
+	return arg
+}
+
+// deleteURLs удаляет ссылки пользователя. Сервис удаляет их асинхронно,
+// поэтому ответ означает, что запрос принят.
+func deleteURLs(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) == 0 {
+		return result{}, usageError{"delete: at least one key is required"}
+	}
+	keys := make([]string, 0, len(args))
+	for _, arg := range args {
+		keys = append(keys, keyFromArg(arg))
+	}
+	resp, err := c.do(http.MethodDelete, "/api/user/urls", keys, http.StatusAccepted)
+	if err != nil {
+		return result{}, err
+	}
+	resp.Body.Close()
+
+	res := result{value: map[string][]string{"accepted": keys}, header: []string{"KEY", "STATUS"}, plain: keys}
+	for _, key := range keys {
+		res.rows = append(res.rows, []string{key, "accepted"})
+	}
+	return res, nil
+}
+
+// resolved — результат команды resolve.
+type resolved struct {
+	Key         string `json:"key"`
+	OriginalURL string `json:"original_url"`
+}
+
+// resolve выводит исходный URL короткой ссылки, не переходя по нему.
+func resolve(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) != 1 {
+		return result{}, usageError{"resolve: exactly one key is required"}
+	}
+	key := keyFromArg(args[0])
+	resp, err := c.do(http.MethodGet, "/"+url.PathEscape(key), nil,
+		http.StatusTemporaryRedirect, http.StatusBadRequest, http.StatusGone, http.StatusForbidden)
+	if err != nil {
+		return result{}, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return result{}, fmt.Errorf("resolve %s: short URL not found", key)
+	case http.StatusGone:
+		return result{}, fmt.Errorf("resolve %s: short URL is deleted", key)
+	case http.StatusForbidden:
+		return result{}, fmt.Errorf("resolve %s: short URL is disabled", key)
+	}
+
+	r := resolved{Key: key, OriginalURL: resp.Header.Get("Location")}
+	return result{
+		value:  r,
+		header: []string{"KEY", "ORIGINAL URL"},
+		rows:   [][]string{{r.Key, r.OriginalURL}},
+		plain:  []string{r.OriginalURL},
+	}, nil
+}
+
+// urlStats — сводка по ссылкам пользователя.
+type urlStats struct {
+	Total    int `json:"total"`
+	Active   int `json:"active"`
+	Deleted  int `json:"deleted"`
+	Disabled int `json:"disabled"`
+}
+
+// stats выводит число ссылок пользователя по состояниям.
+func stats(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) > 0 {
+		return result{}, usageError{"stats: unexpected arguments: " + strings.Join(args, " ")}
+	}
+	urls, err := userURLs(c)
+	if err != nil {
+		return result{}, err
+	}
+	var s urlStats
+	for _, u := range urls {
+		s.Total++
+		switch urlStatus(u) {
+		case "deleted":
+			s.Deleted++
+		case "disabled":
+			s.Disabled++
+		default:
+			s.Active++
+		}
+	}
+
+	res := result{value: s, header: []string{"METRIC", "VALUE"}}
+	for _, row := range []struct {
+		name  string
+		value int
+	}{{"total", s.Total}, {"active", s.Active}, {"deleted", s.Deleted}, {"disabled", s.Disabled}} {
+		res.rows = append(res.rows, []string{row.name, strconv.Itoa(row.value)})
+		res.plain = append(res.plain, row.name+" "+strconv.Itoa(row.value))
+	}
+	return res, nil
+}
+
+// login входит в учётную запись с email и паролем из настроек. Cookie
+// сессии сохраняется в файл cookie и используется следующими командами.
+// С флагом -claim в учётную запись переносятся ссылки, созданные клиентом
+// анонимно.
+func login(c *apiClient, args []string, _ io.Reader) (result, error) {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	claim := fs.Bool("claim", false, "Перенести в учётную запись анонимные ссылки")
+	if err := fs.Parse(args); err != nil {
+		return result{}, usageError{"login: " + err.Error()}
+	}
+	if c.settings.Email == "" || c.settings.Password == "" {
+		return result{}, usageError{"login: email and password are required (-email/-password, SHORTENER_EMAIL/SHORTENER_PASSWORD or profile)"}
+	}
+
+	resp, err := c.do(http.MethodPost, "/api/user/login", models.CredentialsRequest{
+		Email:          c.settings.Email,
+		Password:       c.settings.Password,
+		ClaimAnonymous: *claim,
+	}, http.StatusOK)
+	if err != nil {
+		return result{}, err
+	}
+	var account models.AccountResponse
+	if err := decode(resp, &account); err != nil {
+		return result{}, err
+	}
+	return result{
+		value:  account,
+		header: []string{"USER ID", "EMAIL", "CLAIMED URLS"},
+		rows:   [][]string{{account.UserID, account.Email, strconv.Itoa(account.Claimed)}},
+		plain:  []string{account.UserID},
+	}, nil
+}
+
+// logout завершает сессию на сервисе и удаляет её cookie.
+func logout(c *apiClient, args []string, _ io.Reader) (result, error) {
+	if len(args) > 0 {
+		return result{}, usageError{"logout: unexpected arguments: " + strings.Join(args, " ")}
+	}
+	resp, err := c.do(http.MethodPost, "/api/user/logout", nil, http.StatusNoContent)
+	if err != nil {
+		return result{}, err
+	}
+	resp.Body.Close()
+	return result{value: map[string]bool{"logged_out": true}}, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jarCookie — cookie в файле cookie.
+type jarCookie struct {
+	Name    string     `json:"name"`
+	Value   string     `json:"value"`
+	Path    string     `json:"path"`
+	Secure  bool       `json:"secure,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// expired сообщает, истёк ли срок действия cookie к моменту now.
+func (c jarCookie) expired(now time.Time) bool {
+	return c.Expires != nil && !c.Expires.After(now)
+}
+
+// fileJar — http.CookieJar, сохраняющий cookie в файл, чтобы идентификатор
+// пользователя, сессия и CSRF-токен переживали перезапуск клиента. В отличие
+// от браузера, сессионные cookie тоже сохраняются. Cookie хранятся по
+// адресу сервиса (хост и порт) без учёта атрибута Domain: клиент работает
+// с одним сервисом за раз.
+type fileJar struct {
+	path string
+
+	mu      sync.Mutex
+	cookies map[string][]jarCookie
+}
+
+// loadJar читает cookie из файла path. Отсутствие файла не ошибка; пустой
+// путь означает, что cookie живут только до конца запуска.
+func loadJar(path string) (*fileJar, error) {
+	j := &fileJar{path: path, cookies: make(map[string][]jarCookie)}
+	if path == "" {
+		return j, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cookie jar: %w", err)
+	}
+	if err := json.Unmarshal(data, &j.cookies); err != nil {
+		return nil, fmt.Errorf("read cookie jar %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// SetCookies сохраняет cookie ответа с адреса u. Cookie с истёкшим сроком
+// или отрицательным MaxAge удаляются.
+func (j *fileJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	stored := j.cookies[u.Host]
+	for _, c := range cookies {
+		entry := jarCookie{Name: c.Name, Value: c.Value, Path: c.Path, Secure: c.Secure}
+		if entry.Path == "" || !strings.HasPrefix(entry.Path, "/") {
+			entry.Path = "/"
+		}
+		switch {
+		case c.MaxAge > 0:
+			expires := now.Add(time.Duration(c.MaxAge) * time.Second)
+			entry.Expires = &expires
+		case c.MaxAge == 0 && !c.Expires.IsZero():
+			expires := c.Expires
+			entry.Expires = &expires
+		}
+
+		stored = removeCookie(stored, entry.Name, entry.Path)
+		if c.MaxAge < 0 || entry.expired(now) {
+			continue
+		}
+		stored = append(stored, entry)
+	}
+	j.cookies[u.Host] = stored
+}
+
+// removeCookie удаляет из cookies cookie с именем name и путём path.
+func removeCookie(cookies []jarCookie, name, path string) []jarCookie {
+	kept := cookies[:0]
+	for _, c := range cookies {
+		if c.Name != name || c.Path != path {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// Cookies возвращает действующие cookie для запроса на адрес u.
+func (j *fileJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	var cookies []*http.Cookie
+	for _, c := range j.cookies[u.Host] {
+		if c.expired(now) || (c.Secure && u.Scheme != "https") || !pathMatch(path, c.Path) {
+			continue
+		}
+		cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return cookies
+}
+
+// pathMatch сравнивает путь запроса с атрибутом Path cookie (RFC 6265, 5.1.4).
+func pathMatch(requestPath, cookiePath string) bool {
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return len(requestPath) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+// Cookie возвращает значение cookie name для адреса u.
+func (j *fileJar) Cookie(u *url.URL, name string) (string, bool) {
+	for _, c := range j.Cookies(u) {
+		if c.Name == name {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// Save записывает cookie в файл, пропуская истёкшие. Файл доступен только
+// владельцу: в нём хранятся сессии.
+func (j *fileJar) Save() error {
+	if j.path == "" {
+		return nil
+	}
+	j.mu.Lock()
+	now := time.Now()
+	live := make(map[string][]jarCookie, len(j.cookies))
+	for host, cookies := range j.cookies {
+		for _, c := range cookies {
+			if !c.expired(now) {
+				live[host] = append(live[host], c)
+			}
+		}
+	}
+	j.mu.Unlock()
+
+	data, err := json.MarshalIndent(live, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("save cookie jar: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), ".cookies-*.tmp")
+	if err != nil {
+		return fmt.Errorf("save cookie jar: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save cookie jar: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save cookie jar: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("save cookie jar: %w", err)
+	}
+	return nil
+}
@@ -1,55 +1,96 @@
+// Команда client — клиент командной строки для сервиса сокращения ссылок.
+//
+//	client [флаги] команда [аргументы]
+//
+// Адрес сервиса и учётные данные задаются флагами, переменными окружения
+// SHORTENER_* или профилем из файла профилей. Cookie сохраняются между
+// запусками, поэтому анонимный пользователь остаётся тем же.
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"strings"
 )
 
 func main() {
-	endpoint := "http://localhost:8080/"
-	// контейнер данных для запроса
-	data := url.Values{}
-	// приглашение в консоли
-	fmt.Println("Введите длинный URL")
-	// открываем потоковое чтение из консоли
-	reader := bufio.NewReader(os.Stdin)
-	// читаем строку из консоли
-	long, err := reader.ReadString('\n')
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run выполняет команду клиента и возвращает код завершения: 0 при успехе,
+// 1 при ошибке выполнения и 2 при ошибке в аргументах.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s, rest, err := loadSettings(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		panic(err)
-	}
-	long = strings.TrimSuffix(long, "\n")
-	// заполняем контейнер данными
-	data.Set("url", long)
-	// добавляем HTTP-клиент
-	client := &http.Client{}
-	// пишем запрос
-	// запрос методом POST должен, помимо заголовков, содержать тело
-	// тело должно быть источником потокового чтения io.Reader
-	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+		fmt.Fprintln(stderr, "client:", err)
+		return 2
+	}
+	if len(rest) == 0 {
+		fmt.Fprintln(stderr, "client: command is required, see client -h")
+		return 2
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == rest[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "client: unknown command %q, see client -h\n", rest[0])
+		return 2
+	}
+
+	jarPath := s.CookieJar
+	if jarPath == "-" {
+		jarPath = ""
+	}
+	jar, err := loadJar(jarPath)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(stderr, "client:", err)
+		return 1
 	}
-	// в заголовках запроса указываем кодировку
-	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	// отправляем запрос и получаем ответ
-	response, err := client.Do(request)
+	c, err := newAPIClient(s, jar)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(stderr, "client:", err)
+		return 2
+	}
+
+	res, err := cmd.run(c, rest[1:], stdin)
+	// cookie, выданные сервисом, сохраняются и при ошибке команды
+	if saveErr := jar.Save(); saveErr != nil {
+		fmt.Fprintln(stderr, "client:", saveErr)
+	}
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintln(stderr, "client:", err)
+		return 2
 	}
-	// выводим код ответа
-	fmt.Println("Статус-код ", response.Status)
-	defer response.Body.Close()
-	// читаем поток из тела ответа
-	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(stderr, "client:", err)
+		return 1
+	}
+	if err := render(stdout, s.Output, res); err != nil {
+		fmt.Fprintln(stderr, "client:", err)
+		return 1
+	}
+	return 0
+}
+
+// usage выводит справку по командам и глобальным флагам.
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "usage: client [флаги] команда [аргументы]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Команды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %-10s %s\n", cmd.name, cmd.args, cmd.summary)
 	}
-	// и печатаем его
-	fmt.Println(string(body))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Флаги (переменные окружения SHORTENER_<ФЛАГ>, например SHORTENER_API_KEY):")
+	fs.PrintDefaults()
 }
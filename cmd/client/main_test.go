@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/handler"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/service"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+)
+
+func TestClient(t *testing.T) {
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost", handler.WithCSRF())
+	srv := httptest.NewServer(h.SetupRouter())
+	defer srv.Close()
+	h.SetBaseURL(srv.URL)
+
+	jar := filepath.Join(t.TempDir(), "cookies.json")
+	// каждый вызов — отдельный запуск клиента: состояние переживает его
+	// только через файл cookie
+	client := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-server", srv.URL, "-cookie-jar", jar, "-config", filepath.Join(t.TempDir(), "none.json")}, args...)
+		code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, stderr := client("", "-output", "json", "shorten", "https://example.com/a", "https://example.com/b")
+	require.Equal(t, 0, code, stderr)
+	var shortenedURLs []shortened
+	require.NoError(t, json.Unmarshal([]byte(out), &shortenedURLs))
+	require.Len(t, shortenedURLs, 2)
+	assert.True(t, strings.HasPrefix(shortenedURLs[0].ShortURL, srv.URL+"/"))
+	assert.False(t, shortenedURLs[0].Existed)
+
+	info, err := os.Stat(jar)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	code, out, _ = client("", "shorten", "https://example.com/a")
+	require.Equal(t, 0, code)
+	assert.Equal(t, shortenedURLs[0].ShortURL+"\n", out, "the same user gets the existing link")
+
+	code, out, _ = client("", "list")
+	require.Equal(t, 0, code)
+	assert.ElementsMatch(t, []string{shortenedURLs[0].ShortURL, shortenedURLs[1].ShortURL}, strings.Fields(out))
+
+	code, out, _ = client("# ссылки\nhttps://example.com/c\nmy-id https://example.com/d\n", "-output", "table", "batch")
+	require.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "ORIGINAL", "URL", "SHORT", "URL"}, strings.Fields(lines[0]))
+	assert.Equal(t, "2", strings.Fields(lines[1])[0])
+	assert.Equal(t, "my-id", strings.Fields(lines[2])[0])
+
+	code, out, _ = client("", "resolve", shortenedURLs[1].ShortURL)
+	require.Equal(t, 0, code)
+	assert.Equal(t, "https://example.com/b\n", out)
+
+	// удаление — изменяющий запрос пользователя с cookie: без CSRF-токена
+	// сервис ответил бы 403
+	code, _, stderr = client("", "delete", shortenedURLs[0].ShortURL)
+	require.Equal(t, 0, code, stderr)
+	// удалённые ссылки не попадают в список пользователя
+	require.Eventually(t, func() bool {
+		code, out, _ := client("", "-output", "json", "stats")
+		var s urlStats
+		return code == 0 && json.Unmarshal([]byte(out), &s) == nil && s == urlStats{Total: 3, Active: 3}
+	}, 2*time.Second, 20*time.Millisecond)
+
+	code, _, stderr = client("", "resolve", shortenedURLs[0].ShortURL)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "deleted")
+
+	// без файла cookie клиент — новый анонимный пользователь
+	code, out, _ = client("", "-cookie-jar", "-", "list")
+	require.Equal(t, 0, code)
+	assert.Empty(t, out)
+
+	code, _, _ = client("", "frobnicate")
+	assert.Equal(t, 2, code)
+	code, _, _ = client("", "shorten")
+	assert.Equal(t, 2, code)
+	code, _, _ = client("", "-output", "yaml", "list")
+	assert.Equal(t, 2, code)
+}
+
+func TestLoadSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": "prod",
+		"profiles": {
+			"prod": {"server": "https://sho.rt", "api_key": "usk_prod", "output": "table"},
+			"local": {"server": "http://localhost:9090"}
+		}
+	}`), 0o600))
+
+	s, rest, err := loadSettings([]string{"-config", path, "list"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"list"}, rest)
+	assert.Equal(t, "prod", s.Profile)
+	assert.Equal(t, "https://sho.rt", s.Server)
+	assert.Equal(t, "usk_prod", s.APIKey)
+	assert.Equal(t, outputTable, s.Output)
+
+	t.Setenv("SHORTENER_PROFILE", "local")
+	t.Setenv("SHORTENER_OUTPUT", "json")
+	s, _, err = loadSettings([]string{"-config", path, "list"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9090", s.Server)
+	assert.Empty(t, s.APIKey)
+	assert.Equal(t, outputJSON, s.Output, "environment overrides the profile")
+
+	s, _, err = loadSettings([]string{"-config", path, "-profile", "prod", "-output", "plain", "list"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://sho.rt", s.Server)
+	assert.Equal(t, outputPlain, s.Output, "flags override the environment")
+
+	_, _, err = loadSettings([]string{"-config", path, "-profile", "staging", "list"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `profile "staging" not found`)
+}
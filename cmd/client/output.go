@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// result — результат команды в трёх представлениях: value выводится как
+// JSON, header и rows — таблицей, plain — по строке на значение, удобно
+// для конвейеров оболочки.
+type result struct {
+	value  any
+	header []string
+	rows   [][]string
+	plain  []string
+}
+
+// render выводит результат в формате format.
+func render(w io.Writer, format string, r result) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if len(r.header) > 0 {
+			fmt.Fprintln(tw, strings.Join(r.header, "\t"))
+		}
+		for _, row := range r.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		for _, line := range r.plain {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
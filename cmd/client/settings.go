@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caarlos0/env"
+)
+
+// Форматы вывода.
+const (
+	outputPlain = "plain"
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// settings — настройки клиента. Источники в порядке возрастания приоритета:
+// значения по умолчанию, профиль из файла профилей, переменные окружения
+// и флаги командной строки.
+type settings struct {
+	// Server — адрес сервиса сокращения ссылок
+	Server string `env:"SHORTENER_SERVER" json:"server"`
+	// APIKey — персональный API-ключ (заголовок X-API-Key)
+	APIKey string `env:"SHORTENER_API_KEY" json:"api_key"`
+	// Token — bearer-токен (JWT)
+	Token string `env:"SHORTENER_TOKEN" json:"token"`
+	// Email и Password — учётные данные для команды login
+	Email    string `env:"SHORTENER_EMAIL" json:"email"`
+	Password string `env:"SHORTENER_PASSWORD" json:"password"`
+	// Output — формат вывода: plain, table или json
+	Output string `env:"SHORTENER_OUTPUT" json:"output"`
+	// CookieJar — файл, в котором cookie сохраняются между запусками;
+	// "-" отключает сохранение
+	CookieJar string `env:"SHORTENER_COOKIE_JAR" json:"cookie_jar"`
+	// Timeout — время ожидания ответа сервиса
+	Timeout time.Duration `env:"SHORTENER_TIMEOUT" json:"-"`
+	// Profile — имя профиля в файле профилей
+	Profile string `env:"SHORTENER_PROFILE" json:"-"`
+	// ProfileFile — файл профилей
+	ProfileFile string `env:"SHORTENER_CONFIG" json:"-"`
+}
+
+// profileFile — содержимое файла профилей:
+//
+//	{
+//	  "default": "prod",
+//	  "profiles": {
+//	    "prod": {"server": "https://sho.rt", "api_key": "usk_..."},
+//	    "local": {"server": "http://localhost:8080"}
+//	  }
+//	}
+type profileFile struct {
+	// Default — профиль, используемый, если он не выбран явно.
+	Default  string              `json:"default"`
+	Profiles map[string]settings `json:"profiles"`
+}
+
+// registerFlags регистрирует глобальные флаги клиента.
+func (s *settings) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.Server, "server", "http://localhost:8080", "Адрес сервиса сокращения ссылок")
+	fs.StringVar(&s.APIKey, "api-key", "", "Персональный API-ключ")
+	fs.StringVar(&s.Token, "token", "", "Bearer-токен")
+	fs.StringVar(&s.Email, "email", "", "Email учётной записи для login")
+	fs.StringVar(&s.Password, "password", "", "Пароль учётной записи для login")
+	fs.StringVar(&s.Output, "output", outputPlain, "Формат вывода: plain, table или json")
+	fs.StringVar(&s.CookieJar, "cookie-jar", defaultPath("cookies.json"), `Файл для хранения cookie между запусками, "-" — не сохранять`)
+	fs.DurationVar(&s.Timeout, "timeout", 30*time.Second, "Время ожидания ответа сервиса")
+	fs.StringVar(&s.Profile, "profile", "", "Профиль из файла профилей")
+	fs.StringVar(&s.ProfileFile, "config", defaultPath("client.json"), "Файл профилей")
+}
+
+// defaultPath возвращает путь к файлу клиента в пользовательском каталоге
+// настроек или пустую строку, если каталог неизвестен.
+func defaultPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "shortener", name)
+}
+
+// loadSettings собирает настройки из всех источников и возвращает аргументы,
+// оставшиеся после глобальных флагов: команду и её аргументы.
+func loadSettings(args []string, stderr io.Writer) (*settings, []string, error) {
+	// флаги разбираются дважды: сначала отдельно, чтобы узнать профиль
+	// и набор явно заданных флагов, затем поверх профиля и окружения
+	var fromFlags settings
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(fs) }
+	fromFlags.registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	s := &settings{}
+	defaults := flag.NewFlagSet("client", flag.ContinueOnError)
+	s.registerFlags(defaults)
+
+	profileName, profilePath := os.Getenv("SHORTENER_PROFILE"), os.Getenv("SHORTENER_CONFIG")
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "profile":
+			profileName = fromFlags.Profile
+		case "config":
+			profilePath = fromFlags.ProfileFile
+		}
+	})
+	if profilePath == "" {
+		profilePath = s.ProfileFile
+	}
+	if err := s.loadProfile(profilePath, profileName); err != nil {
+		return nil, nil, err
+	}
+	if err := env.Parse(s); err != nil {
+		return nil, nil, fmt.Errorf("parse environment: %w", err)
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil {
+			err = defaults.Set(f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch s.Output {
+	case outputPlain, outputTable, outputJSON:
+	default:
+		return nil, nil, fmt.Errorf("output: unknown format %q", s.Output)
+	}
+	if s.Server == "" {
+		return nil, nil, errors.New("server: must not be empty")
+	}
+	return s, fs.Args(), nil
+}
+
+// loadProfile применяет к настройкам профиль name из файла path. Если профиль
+// не выбран, используется профиль по умолчанию из файла; отсутствие файла
+// в этом случае не ошибка.
+func (s *settings) loadProfile(path, name string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && name == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read profiles: %w", err)
+	}
+	var file profileFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("read profiles %s: %w", path, err)
+	}
+	if name == "" {
+		name = file.Default
+	}
+	if name == "" {
+		return nil
+	}
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found in %s", name, path)
+	}
+
+	// пустые поля профиля не затирают значения по умолчанию
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&s.Server, profile.Server},
+		{&s.APIKey, profile.APIKey},
+		{&s.Token, profile.Token},
+		{&s.Email, profile.Email},
+		{&s.Password, profile.Password},
+		{&s.Output, profile.Output},
+		{&s.CookieJar, profile.CookieJar},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	s.Profile = name
+	return nil
+}
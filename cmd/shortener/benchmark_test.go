@@ -8,14 +8,13 @@ import (
 	"testing"
 
 	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 	"github.com/AlexeySalamakhin/URLShortener/internal/service"
 	"github.com/AlexeySalamakhin/URLShortener/internal/store"
 )
 
-var benchConfig = config.NewConfigs()
-
 func BenchmarkShortenLogic_InMemoryStore(b *testing.B) {
-	s := service.NewURLShortener(store.NewInMemoryStore())
+	s := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
 	ctx := context.Background()
 	for i := 0; i < b.N; i++ {
 		s.Shorten(ctx, fmt.Sprintf("https://bench/%d", i), "user1")
@@ -25,7 +24,7 @@ func BenchmarkShortenLogic_InMemoryStore(b *testing.B) {
 func BenchmarkShortenLogic_FileStore(b *testing.B) {
 	file, _ := os.CreateTemp("", "filestore_bench_*.tmp")
 	defer os.Remove(file.Name())
-	fs, _ := store.NewFileStore(file.Name())
+	fs, _ := store.NewFileStore(file.Name(), models.DedupGlobal)
 	s := service.NewURLShortener(fs)
 	ctx := context.Background()
 	for i := 0; i < b.N; i++ {
@@ -34,11 +33,14 @@ func BenchmarkShortenLogic_FileStore(b *testing.B) {
 }
 
 func BenchmarkShortenLogic_PostgresStore(b *testing.B) {
-	cfg := benchConfig
+	cfg, err := config.Load(nil)
+	if err != nil {
+		b.Fatalf("Ошибка чтения конфигурации: %v", err)
+	}
 	if cfg.ConnectionString == "" {
 		b.Skip("DATABASE_DSN не задан")
 	}
-	dbStore, err := store.NewDBStore(cfg.ConnectionString)
+	dbStore, err := store.NewDBStore(cfg.ConnectionString, models.DedupGlobal)
 	if err != nil {
 		b.Fatalf("Ошибка подключения к БД: %v", err)
 	}
@@ -50,7 +52,7 @@ func BenchmarkShortenLogic_PostgresStore(b *testing.B) {
 }
 
 func BenchmarkGetUserURLsLogic_InMemoryStore(b *testing.B) {
-	s := service.NewURLShortener(store.NewInMemoryStore())
+	s := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
 	ctx := context.Background()
 	userID := "user1"
 	for i := 0; i < 1000; i++ {
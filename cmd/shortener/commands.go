@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+)
+
+// runCommand выполняет подкоманду args[0] и возвращает код завершения.
+// Поддерживаемые подкоманды:
+//
+//	config print [флаги]  — вывести итоговую конфигурацию без секретов
+//	version [-json]       — вывести сведения о сборке
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	switch {
+	case len(args) >= 2 && args[0] == "config" && args[1] == "print":
+		return configPrint(args[2:], stdout, stderr)
+	case args[0] == "version":
+		return version(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(stderr, "usage: shortener [flags] | shortener config print [flags] | shortener version [-json]")
+		return 2
+	}
+}
+
+// version выводит сведения о сборке: кратко или, с флагом -json, вместе
+// с зависимостями модуля в формате JSON.
+func version(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "Вывести сведения о сборке и зависимостях в формате JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	info := buildinfo.Read(buildVersion, buildDate, buildCommit)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	printBuildInfo(stdout, info)
+	fmt.Fprintln(stdout, "Go version:", info.GoVersion)
+	return 0
+}
+
+// configPrint выводит конфигурацию, собранную из флагов args, окружения и
+// файла, в формате JSON со скрытыми секретами, а затем сообщает об ошибках
+// проверки. Код завершения 1 означает некорректную конфигурацию.
+func configPrint(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(stderr, "invalid configuration:")
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPrint(t *testing.T) {
+	t.Setenv("JWT_SECRET", "top-secret-value")
+
+	var stdout, stderr bytes.Buffer
+	code := runCommand([]string{"config", "print", "-d", "postgres://app:hunter2@db/urls", "-a", ":9090"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NotContains(t, stdout.String(), "top-secret-value")
+	assert.NotContains(t, stdout.String(), "hunter2")
+
+	var printed map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &printed))
+	assert.Equal(t, ":9090", printed["server_address"])
+	assert.Equal(t, "[REDACTED]", printed["jwt_secret"])
+	assert.Equal(t, "postgres://app:[REDACTED]@db/urls", printed["database_dsn"])
+
+	stdout.Reset()
+	stderr.Reset()
+	code = runCommand([]string{"config", "print", "-b", "nowhere"}, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "base_url")
+
+	code = runCommand([]string{"frobnicate"}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+}
+
+func TestVersionCommand(t *testing.T) {
+	prev := buildVersion
+	buildVersion = "v9.9.9"
+	t.Cleanup(func() { buildVersion = prev })
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, runCommand([]string{"version"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Build version: v9.9.9")
+	assert.Contains(t, stdout.String(), "Go version: go")
+
+	stdout.Reset()
+	require.Equal(t, 0, runCommand([]string{"version", "-json"}, &stdout, &stderr))
+	var info map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &info))
+	assert.Equal(t, "v9.9.9", info["version"])
+	assert.Contains(t, info, "go_version")
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run([]string{"version"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Build version:")
+
+	// профилирование без служебного адреса — некорректная конфигурация
+	assert.Equal(t, 2, run([]string{"-pprof"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Некорректная конфигурация")
+}
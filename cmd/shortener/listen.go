@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+	"github.com/AlexeySalamakhin/URLShortener/internal/listener"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+)
+
+// openListeners открывает слушателей основного сервера (ServerAddr и Listen)
+// и служебного (AdminAddr, если задан). При ошибке уже открытые слушатели
+// закрываются.
+func openListeners(cfg *config.Config) (public, admin []net.Listener, err error) {
+	mode, err := listener.ParseMode(cfg.UnixSocketMode)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := listener.Options{UnixMode: mode, UnixGroup: cfg.UnixSocketGroup}
+
+	defer func() {
+		if err != nil {
+			closeListeners(public)
+			closeListeners(admin)
+		}
+	}()
+
+	var addrs []string
+	if cfg.ServerAddr != "" {
+		addrs = append(addrs, cfg.ServerAddr)
+	}
+	for _, addr := range strings.Split(cfg.Listen, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, nil, errors.New("no listen addresses configured")
+	}
+	for _, addr := range addrs {
+		ls, err := listener.Listen(addr, opts)
+		if err != nil {
+			return public, admin, err
+		}
+		public = append(public, ls...)
+	}
+
+	if cfg.AdminAddr != "" {
+		if admin, err = listener.Listen(cfg.AdminAddr, opts); err != nil {
+			return public, admin, err
+		}
+	}
+	return public, admin, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// serve запускает server на каждом из listeners, по HTTPS, если у сервера
+// заданы настройки TLS. Результат каждого Serve отправляется в errCh.
+func serve(server *http.Server, listeners []net.Listener, errCh chan<- error) {
+	// Serve сам заполняет TLSConfig для HTTP/2, поэтому режим определяется заранее
+	useTLS := server.TLSConfig != nil
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if useTLS {
+				logger.Log.Info("Запуск HTTPS-сервера...", zap.Stringer("addr", l.Addr()))
+				errCh <- server.ServeTLS(l, "", "")
+				return
+			}
+			logger.Log.Info("Запуск HTTP-сервера...", zap.Stringer("addr", l.Addr()))
+			errCh <- server.Serve(l)
+		}(l)
+	}
+}
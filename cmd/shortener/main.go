@@ -2,21 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
 	"github.com/AlexeySalamakhin/URLShortener/internal/config"
 	"github.com/AlexeySalamakhin/URLShortener/internal/handler"
+	"github.com/AlexeySalamakhin/URLShortener/internal/health"
 	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/metrics"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/profiling"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
 	"github.com/AlexeySalamakhin/URLShortener/internal/service"
 	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tracing"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/acme/autocert"
 )
 
 var (
@@ -24,89 +37,428 @@ var (
 )
 
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run запускает сервер или выполняет подкоманду и возвращает код завершения:
+// 0 при успехе, 1 при ошибке сервера и 2 при некорректной конфигурации.
+func run(args []string, stdout, stderr io.Writer) int {
+	// первый аргумент без дефиса — подкоманда, а не флаг сервера
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return runCommand(args, stdout, stderr)
+	}
+
+	config, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Некорректная конфигурация:")
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
 	// Вывод информации о сборке
-	setBuildInfoDefaults()
-	printBuildInfo()
+	info := buildinfo.Read(buildVersion, buildDate, buildCommit)
+	printBuildInfo(stdout, info)
+
+	if err := logger.Configure(logger.Options{
+		Level:      config.LogLevel,
+		Format:     config.LogFormat,
+		Sampling:   config.LogSampling,
+		File:       config.LogFile,
+		MaxSizeMB:  config.LogMaxSizeMB,
+		MaxBackups: config.LogMaxBackups,
+	}); err != nil {
+		panic(err)
+	}
+	defer logger.Log.Sync()
+
+	dedupScope, err := models.ParseDedupScope(config.DedupScope)
+	if err != nil {
+		logger.Log.Error("Invalid dedup scope: " + err.Error())
+		panic(err)
+	}
 
-	config := config.NewConfigs()
-	flag.Parse()
-	logger.Initialize("info")
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     config.TraceExporter,
+		OTLPEndpoint: config.TraceOTLPEndpoint,
+		OTLPInsecure: config.TraceOTLPInsecure,
+		SampleRatio:  config.TraceSampleRatio,
+		ServiceName:  "shortener",
+	})
+	if err != nil {
+		logger.Log.Error("Failed to initialize tracing: " + err.Error())
+		panic(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Log.Error("Failed to flush traces: " + err.Error())
+		}
+	}()
+
+	appMetrics := metrics.New()
+	appMetrics.SetBuildInfo(info, store.Backend(config), buildinfo.StartTime)
 
-	store, err := store.InitStore(config)
+	baseStore, err := store.InitStore(config, dedupScope)
 	if err != nil {
 		logger.Log.Error("Failed to initialize store: " + err.Error())
 		panic(err)
 	}
+	urlStore := store.NewObservedStore(baseStore, appMetrics.StoreHook, tracing.StoreHook, logger.StoreHook)
 	defer func() {
-		if err := store.Close(); err != nil {
+		if err := urlStore.Close(); err != nil {
 			logger.Log.Error("Failed to close store: " + err.Error())
 		}
 	}()
 
-	urlShortener := service.NewURLShortener(store)
-	urlHandler := handler.NewURLHandler(urlShortener, config.BaseURL)
-	r := urlHandler.SetupRouter()
+	checker := newHealthChecker(config, baseStore)
+
+	signer, err := newCookieSigner(config)
+	if err != nil {
+		logger.Log.Error("Failed to initialize cookie signer: " + err.Error())
+		panic(err)
+	}
+
+	urlShortener := service.NewURLShortener(urlStore,
+		service.WithDedupScope(dedupScope),
+		service.WithSessionTTL(time.Duration(config.SessionTTL)),
+	)
+
+	authenticators, err := newAuthenticators(config, urlShortener)
+	if err != nil {
+		logger.Log.Error("Failed to initialize authenticators: " + err.Error())
+		panic(err)
+	}
+
+	limiter, err := newRateLimiter(config)
+	if err != nil {
+		logger.Log.Error("Failed to initialize rate limiter: " + err.Error())
+		panic(err)
+	}
+
+	handlerOpts := []handler.Option{
+		handler.WithCookieSigner(signer),
+		handler.WithAuthenticators(authenticators...),
+		handler.WithMetrics(appMetrics),
+		handler.WithHealth(checker),
+		handler.WithBuildInfo(info, store.Backend(config)),
+		handler.WithRateLimiter(limiter),
+		handler.WithLogLevel(logger.Level()),
+		handler.WithTimeouts(time.Duration(config.HandlerTimeout), map[string]time.Duration{
+			ratelimit.GroupBatch: time.Duration(config.BatchHandlerTimeout),
+		}),
+		handler.WithBodyLimits(config.MaxBodyBytes, config.MaxBatchBodyBytes),
+		handler.WithCompressMinSize(config.CompressMinSize),
+		handler.WithAPIKeys(urlShortener),
+		handler.WithAccounts(urlShortener),
+		handler.WithTeams(urlShortener),
+		handler.WithAdmin(urlShortener, auth.NewAdminPolicy(strings.Split(config.AdminUserIDs, ","), config.AdminScope).IsAdmin),
+	}
+	handlerOpts = append(handlerOpts, securityOptions(config)...)
+	if config.OIDCIssuer != "" {
+		oidc, err := auth.NewOIDCProvider(auth.OIDCConfig{
+			Issuer:       config.OIDCIssuer,
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  config.OIDCRedirectURL,
+			Scopes:       strings.Split(config.OIDCScopes, ","),
+		})
+		if err != nil {
+			logger.Log.Error("Failed to initialize OIDC provider: " + err.Error())
+			panic(err)
+		}
+		handlerOpts = append(handlerOpts, handler.WithOIDC(oidc))
+	}
+	if config.AdminAddr != "" {
+		handlerOpts = append(handlerOpts, handler.WithSeparateAdmin())
+	}
+	if config.EnablePprof {
+		handlerOpts = append(handlerOpts, handler.WithProfiling())
+	}
+	if config.PprofBlockRate > 0 {
+		runtime.SetBlockProfileRate(config.PprofBlockRate)
+	}
+	if config.PprofMutexFraction > 0 {
+		runtime.SetMutexProfileFraction(config.PprofMutexFraction)
+	}
+	urlHandler := handler.NewURLHandler(urlShortener, config.BaseURL, handlerOpts...)
+	urlHandler.SetConfigFingerprint(config.Fingerprint())
+
+	publicListeners, adminListeners, err := openListeners(config)
+	if err != nil {
+		logger.Log.Error("Failed to open listeners: " + err.Error())
+		panic(err)
+	}
 
 	server := &http.Server{
-		Addr:    config.ServerAddr,
-		Handler: r,
+		Handler:           urlHandler.SetupRouter(),
+		ReadTimeout:       time.Duration(config.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(config.WriteTimeout),
+		IdleTimeout:       time.Duration(config.IdleTimeout),
+	}
+	// служебный сервер работает без TLS: он доступен только во внутренней сети
+	adminServer := &http.Server{
+		Handler:           urlHandler.SetupAdminRouter(),
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout),
+		IdleTimeout:       time.Duration(config.IdleTimeout),
 	}
 
 	// Канал для сигналов завершения
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-	// Канал для ошибок сервера
-	errCh := make(chan error, 1)
+	// Канал для ошибок серверов: по одному на слушателя и сервер перенаправления
+	errCh := make(chan error, len(publicListeners)+len(adminListeners)+1)
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
 
-	go func() {
-		if config.EnableHTTPS {
-			manager := &autocert.Manager{
-				Cache:  autocert.DirCache(".autocert-cache"),
-				Prompt: autocert.AcceptTOS,
+	var redirectServer *http.Server
+	if config.EnableHTTPS {
+		setup, err := newTLSSetup(config)
+		if err != nil {
+			logger.Log.Error("Failed to initialize TLS: " + err.Error())
+			panic(err)
+		}
+		server.TLSConfig = setup.config
+		if setup.reloader != nil {
+			go setup.reloader.Watch(watchCtx, time.Duration(config.TLSReloadInterval))
+		}
+		if config.HTTPRedirectAddr != "" {
+			redirectServer = &http.Server{
+				Addr:              config.HTTPRedirectAddr,
+				Handler:           setup.redirect,
+				ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout),
+				IdleTimeout:       time.Duration(config.IdleTimeout),
 			}
-			server.TLSConfig = manager.TLSConfig()
-			logger.Log.Info("Запуск HTTPS-сервера с autocert...", zap.String("addr", config.ServerAddr))
-			errCh <- server.ListenAndServeTLS("", "")
-		} else {
-			logger.Log.Info("Запуск HTTP-сервера...", zap.String("addr", config.ServerAddr))
-			errCh <- server.ListenAndServe()
 		}
-	}()
+	}
+
+	if config.ProfileDir != "" {
+		profiler, err := profiling.New(profiling.Options{
+			Dir:         config.ProfileDir,
+			Interval:    time.Duration(config.ProfileInterval),
+			CPUDuration: time.Duration(config.ProfileCPUDuration),
+			Keep:        config.ProfileKeep,
+		})
+		if err != nil {
+			logger.Log.Error("Failed to initialize profiler: " + err.Error())
+			panic(err)
+		}
+		logger.Log.Info("Непрерывное профилирование включено", zap.String("dir", config.ProfileDir))
+		go profiler.Run(watchCtx)
+	}
+
+	serve(server, publicListeners, errCh)
+	if len(adminListeners) > 0 {
+		logger.Log.Info("Служебные маршруты вынесены на отдельный слушатель", zap.String("addr", config.AdminAddr))
+		serve(adminServer, adminListeners, errCh)
+	}
+	if redirectServer != nil {
+		go func() {
+			logger.Log.Info("Запуск сервера перенаправления на HTTPS...", zap.String("addr", redirectServer.Addr))
+			errCh <- redirectServer.ListenAndServe()
+		}()
+	}
+
+	// Перечитывание конфигурации по SIGHUP и, если включено, при изменении
+	// файлов конфигурации и секретов, прочитанных при запуске
+	reloadCh := make(chan struct{}, 1)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	rl := &reloader{current: config, handler: urlHandler, limiter: limiter}
+	if config.ConfigPath != "" && config.ConfigWatchInterval > 0 {
+		go watchFiles(watchCtx, rl.files, time.Duration(config.ConfigWatchInterval), reloadCh)
+	}
+	reload := func() {
+		if err := rl.reload(); err != nil {
+			logger.Log.Error("Новая конфигурация отклонена", zap.Error(err))
+		}
+	}
 
-	select {
-	case sig := <-sigCh:
-		logger.Log.Info("Получен сигнал завершения", zap.String("signal", sig.String()))
-	case err := <-errCh:
-		if err != nil && err != http.ErrServerClosed {
-			logger.Log.Error("Ошибка сервера", zap.Error(err))
-			os.Exit(1)
+	exitCode := 0
+wait:
+	for {
+		select {
+		case <-hupCh:
+			reload()
+		case <-reloadCh:
+			reload()
+		case sig := <-sigCh:
+			logger.Log.Info("Получен сигнал завершения", zap.String("signal", sig.String()))
+			break wait
+		case err := <-errCh:
+			if err != nil && err != http.ErrServerClosed {
+				logger.Log.Error("Ошибка сервера", zap.Error(err))
+				exitCode = 1
+			}
+			break wait
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// /readyz отвечает 503 с этого момента и до конца завершения; служебный
+	// сервер останавливается последним, чтобы проверки видели это состояние
+	checker.SetDraining()
+	if delay := time.Duration(config.ShutdownDelay); delay > 0 {
+		logger.Log.Info("Ожидание исключения из балансировки", zap.Duration("delay", delay))
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout))
 	defer cancel()
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			logger.Log.Error("Ошибка при завершении сервера перенаправления", zap.Error(err))
+		}
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Log.Error("Ошибка при завершении сервера", zap.Error(err))
 	} else {
 		logger.Log.Info("Сервер завершён корректно")
 	}
+	if err := adminServer.Shutdown(ctx); err != nil {
+		logger.Log.Error("Ошибка при завершении служебного сервера", zap.Error(err))
+	}
+	return exitCode
 }
 
-func setBuildInfoDefaults() {
-	if buildVersion == "" {
-		buildVersion = "N/A"
+// securityOptions возвращает настройки защиты обработчика для браузеров:
+// CORS, CSRF и HSTS, если они включены в конфигурации.
+func securityOptions(cfg *config.Config) []handler.Option {
+	var opts []handler.Option
+	if cfg.CORSAllowedOrigins != "" {
+		opts = append(opts, handler.WithCORS(middleware.CORSOptions{
+			AllowedOrigins:   strings.Split(cfg.CORSAllowedOrigins, ","),
+			AllowedMethods:   strings.Split(cfg.CORSAllowedMethods, ","),
+			AllowedHeaders:   strings.Split(cfg.CORSAllowedHeaders, ","),
+			ExposedHeaders:   []string{middleware.CSRFHeader, middleware.RequestIDHeader, handler.LinkOwnerHeader},
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           time.Duration(cfg.CORSMaxAge),
+		}))
+	}
+	if cfg.CSRFProtection {
+		opts = append(opts, handler.WithCSRF())
 	}
-	if buildDate == "" {
-		buildDate = "N/A"
+	if cfg.EnableHTTPS {
+		opts = append(opts, handler.WithHSTS(time.Duration(cfg.HSTSMaxAge)))
 	}
-	if buildCommit == "" {
-		buildCommit = "N/A"
+	return opts
+}
+
+// newHealthChecker регистрирует проверки зависимостей для /readyz и /health:
+// доступность хранилища, а для файлового хранилища — ещё и свободное место
+// на диске.
+func newHealthChecker(cfg *config.Config, s store.Store) *health.Checker {
+	checker := health.New(time.Duration(cfg.HealthCheckTimeout))
+	checker.Register("store", s.Ping, true)
+	if _, ok := s.(*store.FileStore); ok {
+		checker.Register("disk_space", health.DiskSpace(filepath.Dir(cfg.File), uint64(cfg.HealthMinFreeBytes)), true)
+	}
+	return checker
+}
+
+// newCookieSigner собирает ключи подписи cookie из конфигурации и файла ключей.
+// Если ключи не заданы, генерируется случайный ключ и cookie перестают
+// приниматься после перезапуска сервиса.
+func newCookieSigner(cfg *config.Config) (*auth.CookieSigner, error) {
+	keys, err := auth.ParseKeys(cfg.CookieKeys)
+	if err != nil {
+		return nil, err
 	}
+	if cfg.CookieKeysFile != "" {
+		fileKeys, err := auth.LoadKeysFile(cfg.CookieKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) == 0 {
+		logger.Log.Warn("Ключи подписи cookie не заданы, используется случайный ключ")
+		keys = []auth.Key{auth.NewRandomKey()}
+	}
+
+	sameSite, err := auth.ParseSameSite(cfg.CookieSameSite)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewCookieSigner(keys, cfg.CookieActiveKey, auth.CookieOptions{
+		Secure:   cfg.CookieSecure || cfg.EnableHTTPS,
+		SameSite: sameSite,
+		MaxAge:   time.Duration(cfg.CookieMaxAge),
+	})
+}
+
+// newRateLimiter создаёт ограничитель частоты запросов с состоянием в памяти.
+func newRateLimiter(cfg *config.Config) (*ratelimit.Limiter, error) {
+	limits, err := rateLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits, strings.Split(cfg.TrustedProxies, ","))
+}
+
+// rateLimits разбирает лимиты групп маршрутов из конфигурации.
+func rateLimits(cfg *config.Config) (map[string]ratelimit.Limit, error) {
+	raw := map[string]string{
+		ratelimit.GroupShorten:  cfg.RateLimitShorten,
+		ratelimit.GroupBatch:    cfg.RateLimitBatch,
+		ratelimit.GroupRedirect: cfg.RateLimitRedirect,
+		ratelimit.GroupAPI:      cfg.RateLimitAPI,
+		ratelimit.GroupAuth:     cfg.RateLimitAuth,
+	}
+	limits := make(map[string]ratelimit.Limit, len(raw))
+	for group, value := range raw {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// newAuthenticators создаёт аутентификаторы, проверяемые до cookie user_id.
+func newAuthenticators(cfg *config.Config, shortener *service.URLShortener) ([]auth.Authenticator, error) {
+	authenticators := []auth.Authenticator{
+		auth.NewAPIKeyAuthenticator(shortener.AuthenticateAPIKey),
+	}
+
+	if cfg.JWTSecret != "" || cfg.JWTPublicKeyFile != "" {
+		jwtCfg := auth.JWTConfig{
+			HMACSecret: []byte(cfg.JWTSecret),
+			Issuer:     cfg.JWTIssuer,
+			Audience:   cfg.JWTAudience,
+			Leeway:     30 * time.Second,
+		}
+		if cfg.JWTPublicKeyFile != "" {
+			key, err := auth.LoadRSAPublicKey(cfg.JWTPublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			jwtCfg.RSAPublicKey = key
+		}
+		jwtAuth, err := auth.NewJWTAuthenticator(jwtCfg)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuth)
+	}
+
+	// сессия проверяется после учётных данных из заголовков
+	authenticators = append(authenticators, auth.NewSessionAuthenticator(shortener.AuthenticateSession))
+	return authenticators, nil
 }
 
-func printBuildInfo() {
-	fmt.Println("Build version:", buildVersion)
-	fmt.Println("Build date:", buildDate)
-	fmt.Println("Build commit:", buildCommit)
+// printBuildInfo выводит в w сведения о сборке.
+func printBuildInfo(w io.Writer, info buildinfo.Info) {
+	fmt.Fprintln(w, "Build version:", info.Version)
+	fmt.Fprintln(w, "Build date:", info.Date)
+	fmt.Fprintln(w, "Build commit:", info.Commit)
 }
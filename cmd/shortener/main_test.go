@@ -4,22 +4,45 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth/oidctest"
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
 	"github.com/AlexeySalamakhin/URLShortener/internal/handler"
+	"github.com/AlexeySalamakhin/URLShortener/internal/health"
+	"github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/metrics"
 	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
 	"github.com/AlexeySalamakhin/URLShortener/internal/service"
 	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tracing"
 )
 
 type contextKey string
@@ -49,7 +72,7 @@ func TestPostURLHandlerText(t *testing.T) {
 			userID:       "test-user",
 		},
 	}
-	shortener := service.NewURLShortener(store.NewInMemoryStore())
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
 	handler := handler.NewURLHandler(shortener, "localhost:8080")
 	for _, tc := range testCases {
 		t.Run(tc.method, func(t *testing.T) {
@@ -85,7 +108,7 @@ func TestGetURLHandler(t *testing.T) {
 		},
 	}
 
-	shortener := service.NewURLShortener(store.NewInMemoryStore())
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
 	handler := handler.NewURLHandler(shortener, "localhost:8080")
 	for _, tc := range testCases {
 		t.Run(tc.method, func(t *testing.T) {
@@ -113,9 +136,9 @@ func (m *MockShortener) StoreReady() bool {
 	return true
 }
 
-func (m *MockShortener) Shorten(ctx context.Context, url string, userID string) (string, bool) {
+func (m *MockShortener) Shorten(ctx context.Context, url string, userID string) (models.ShortenResult, error) {
 	args := m.Called(ctx, url, userID)
-	return args.String(0), args.Bool(1)
+	return args.Get(0).(models.ShortenResult), args.Error(1)
 }
 
 func (m *MockShortener) GetOriginalURL(ctx context.Context, url string) (models.UserURLsResponse, bool) {
@@ -167,7 +190,7 @@ func TestPostURLHandlerJson(t *testing.T) {
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			mockShortener := new(MockShortener)
-			mockShortener.On("Shorten", mock.Anything, tt.input.URL, tt.userID).Return(tt.mockShortKey, false)
+			mockShortener.On("Shorten", mock.Anything, tt.input.URL, tt.userID).Return(models.ShortenResult{ShortURL: tt.mockShortKey}, nil)
 
 			handler := handler.NewURLHandler(mockShortener, "http://localhost:8080")
 			body, _ := json.Marshal(tt.input)
@@ -271,3 +294,1127 @@ func TestGetUserURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestShortenDedupScope(t *testing.T) {
+	type call struct {
+		userID       string
+		expectedCode int
+		expectedOwn  string
+	}
+	testCases := []struct {
+		name      string
+		scope     models.DedupScope
+		calls     []call
+		sameShort bool
+	}{
+		{
+			name:  "global",
+			scope: models.DedupGlobal,
+			calls: []call{
+				{userID: "user-a", expectedCode: http.StatusCreated},
+				{userID: "user-a", expectedCode: http.StatusConflict, expectedOwn: "self"},
+				{userID: "user-b", expectedCode: http.StatusConflict, expectedOwn: "other"},
+			},
+			sameShort: true,
+		},
+		{
+			name:  "user",
+			scope: models.DedupUser,
+			calls: []call{
+				{userID: "user-a", expectedCode: http.StatusCreated},
+				{userID: "user-b", expectedCode: http.StatusCreated},
+			},
+		},
+		{
+			name:  "none",
+			scope: models.DedupNone,
+			calls: []call{
+				{userID: "user-a", expectedCode: http.StatusCreated},
+				{userID: "user-a", expectedCode: http.StatusCreated},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shortener := service.NewURLShortener(store.NewInMemoryStore(tc.scope), service.WithDedupScope(tc.scope))
+			h := handler.NewURLHandler(shortener, "http://localhost:8080")
+
+			results := make(map[string]bool)
+			for _, c := range tc.calls {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://practicum.yandex.ru"))
+				r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, c.userID))
+				w := httptest.NewRecorder()
+				h.PostURLHandlerText(w, r)
+
+				require.Equal(t, c.expectedCode, w.Code)
+				assert.Equal(t, c.expectedOwn, w.Header().Get(handler.LinkOwnerHeader))
+				results[w.Body.String()] = true
+			}
+			if tc.sameShort {
+				assert.Len(t, results, 1)
+			} else {
+				assert.Len(t, results, len(tc.calls))
+			}
+
+			if tc.scope == models.DedupUser {
+				urls, err := shortener.GetUserURLs(context.Background(), "user-b")
+				require.NoError(t, err)
+				assert.Len(t, urls, 1)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareBearer(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080", handler.WithAuthenticators(jwtAuth))
+	router := h.SetupRouter()
+
+	validToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "service-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectCookie   bool
+	}{
+		{name: "anonymous", expectedStatus: http.StatusCreated, expectCookie: true},
+		{name: "valid token", authorization: "Bearer " + validToken, expectedStatus: http.StatusCreated},
+		{name: "invalid token", authorization: "Bearer " + validToken + "x", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com/"+tc.name))
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectCookie, len(w.Result().Cookies()) > 0)
+			if tc.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	urls, err := shortener.GetUserURLs(context.Background(), "service-1")
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+}
+
+func TestAPIKeys(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "urls_*.jsonl")
+	require.NoError(t, err)
+	file.Close()
+	fs, err := store.NewFileStore(file.Name(), models.DedupGlobal)
+	require.NoError(t, err)
+	defer fs.Close()
+
+	shortener := service.NewURLShortener(fs)
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(auth.NewAPIKeyAuthenticator(shortener.AuthenticateAPIKey)),
+		handler.WithAPIKeys(shortener),
+	)
+	router := h.SetupRouter()
+
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, values := range header {
+			for _, v := range values {
+				r.Header.Add(k, v)
+			}
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// ключ выпускается пользователю из cookie
+	w := do(http.MethodPost, "/api/user/keys", `{"name":"ci"}`, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	cookie := w.Result().Cookies()[0]
+	var created models.APIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	require.NotEmpty(t, created.Key)
+
+	cookieHeader := http.Header{"Cookie": {cookie.Name + "=" + cookie.Value}}
+	keyHeader := http.Header{auth.APIKeyHeader: {created.Key}}
+
+	// ссылка, сокращённая по ключу, видна пользователю из cookie
+	w = do(http.MethodPost, "/", "https://example.com/release-notes", keyHeader)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	w = do(http.MethodGet, "/api/user/urls", "", cookieHeader)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// в списке ключей нет самого ключа
+	w = do(http.MethodGet, "/api/user/keys", "", cookieHeader)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.Contains(t, w.Body.String(), created.Prefix)
+
+	// ключи хранятся только в виде хэша и переживают перезапуск
+	data, err := os.ReadFile(file.Name() + ".apikeys")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), created.Key)
+	assert.Contains(t, string(data), auth.HashAPIKey(created.Key))
+
+	// после отзыва ключ не принимается
+	w = do(http.MethodDelete, "/api/user/keys", `["`+created.ID+`"]`, cookieHeader)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodPost, "/", "https://example.com/other", keyHeader)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"), "a rejected API key gets no bearer challenge")
+}
+
+func TestAccounts(t *testing.T) {
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(auth.NewSessionAuthenticator(shortener.AuthenticateSession)),
+		handler.WithAccounts(shortener),
+	)
+	router := h.SetupRouter()
+
+	// client хранит cookie между запросами, как браузер
+	type client struct{ cookies map[string]*http.Cookie }
+	newClient := func() *client { return &client{cookies: make(map[string]*http.Cookie)} }
+	do := func(c *client, method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for _, cookie := range c.cookies {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.MaxAge < 0 {
+				delete(c.cookies, cookie.Name)
+			} else {
+				c.cookies[cookie.Name] = cookie
+			}
+		}
+		return w
+	}
+
+	browser := newClient()
+	require.Equal(t, http.StatusCreated, do(browser, http.MethodPost, "/", "https://example.com/anon").Code)
+
+	w := do(browser, http.MethodPost, "/api/user/register", `{"email":"User@Example.com","password":"short"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(browser, http.MethodPost, "/api/user/register", `{"email":"User@Example.com","password":"correct horse","claim_anonymous":true}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var account models.AccountResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&account))
+	assert.Equal(t, "user@example.com", account.Email)
+	assert.Equal(t, 1, account.Claimed)
+
+	w = do(browser, http.MethodGet, "/api/user/urls", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/anon")
+
+	w = do(newClient(), http.MethodPost, "/api/user/register", `{"email":"user@example.com","password":"another one"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	require.Equal(t, http.StatusNoContent, do(browser, http.MethodPost, "/api/user/logout", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(browser, http.MethodGet, "/api/user/urls", "").Code)
+
+	other := newClient()
+	w = do(other, http.MethodPost, "/api/user/login", `{"email":"user@example.com","password":"wrong password"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = do(other, http.MethodPost, "/api/user/login", `{"email":"user@example.com","password":"correct horse"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = do(other, http.MethodGet, "/api/user/urls", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/anon")
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := oidctest.NewProvider("shortener")
+	defer provider.Close()
+
+	oidc, err := auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:      provider.Issuer(),
+		ClientID:    "shortener",
+		RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+	require.NoError(t, err)
+
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(auth.NewSessionAuthenticator(shortener.AuthenticateSession)),
+		handler.WithAccounts(shortener),
+		handler.WithOIDC(oidc),
+	)
+	router := h.SetupRouter()
+
+	cookies := make(map[string]*http.Cookie)
+	do := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.MaxAge < 0 {
+				delete(cookies, cookie.Name)
+			} else {
+				cookies[cookie.Name] = cookie
+			}
+		}
+		return w
+	}
+
+	anon := httptest.NewRecorder()
+	router.ServeHTTP(anon, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com/sso")))
+	require.Equal(t, http.StatusCreated, anon.Code)
+	for _, cookie := range anon.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	// начало входа перенаправляет на провайдера
+	w := do("/api/auth/oidc/login?claim_anonymous=true&return_to=/api/user/urls")
+	require.Equal(t, http.StatusFound, w.Code)
+
+	// провайдер сразу подтверждает вход и возвращает на адрес обратного вызова
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	tampered := callback.Query()
+	tampered.Set("state", "forged")
+	assert.Equal(t, http.StatusBadRequest, do(callback.Path+"?"+tampered.Encode()).Code)
+
+	// неудачная попытка удалила cookie со state, поэтому вход начинается заново
+	w = do("/api/auth/oidc/login?claim_anonymous=true&return_to=/api/user/urls")
+	require.Equal(t, http.StatusFound, w.Code)
+	resp, err = noRedirect.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	callback, err = url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	w = do(callback.RequestURI())
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/api/user/urls", w.Header().Get("Location"))
+	require.Contains(t, cookies, auth.SessionCookieName)
+
+	// повторный обратный вызов отклоняется: cookie со state уже удалена
+	assert.Equal(t, http.StatusBadRequest, do(callback.RequestURI()).Code)
+
+	delete(cookies, auth.CookieName)
+	w = do("/api/user/urls")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/sso")
+}
+
+func TestAdminURLs(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+	token := func(sub, scope string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":   sub,
+			"scope": scope,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupUser), service.WithDedupScope(models.DedupUser))
+	policy := auth.NewAdminPolicy([]string{"root"}, auth.DefaultAdminScope)
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(jwtAuth),
+		handler.WithAdmin(shortener, policy.IsAdmin),
+	)
+	router := h.SetupRouter()
+
+	ctx := context.Background()
+	alice, err := shortener.Shorten(ctx, "https://example.com/alice", "alice")
+	require.NoError(t, err)
+	_, err = shortener.Shorten(ctx, "https://example.com/bob", "bob")
+	require.NoError(t, err)
+	_, err = shortener.Shorten(ctx, "https://example.com/alice", "bob")
+	require.NoError(t, err)
+
+	do := func(method, target, authorization, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/admin/urls", "", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/admin/urls", token("alice", "read"), "").Code)
+
+	root := token("root", "")
+	w := do(http.MethodGet, "/api/admin/urls?q=ALICE", root, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var found []models.AdminURLResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&found))
+	require.Len(t, found, 2)
+
+	w = do(http.MethodGet, "/api/admin/urls?user_id=bob&limit=1", token("ops", "admin"), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	found = nil
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&found))
+	require.Len(t, found, 1)
+	assert.Equal(t, "bob", found[0].UserID)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/admin/urls?limit=-1", root, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/admin/urls/missing", root, "").Code)
+
+	key := alice.ShortURL
+	w = do(http.MethodPatch, "/api/admin/urls/"+key, root, `{"disabled":true}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/"+key, "", "").Code)
+
+	w = do(http.MethodPatch, "/api/admin/urls/"+key, root, `{"disabled":false}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, "/"+key, "", "").Code)
+
+	// у bob уже есть ссылка на этот URL
+	assert.Equal(t, http.StatusConflict, do(http.MethodPatch, "/api/admin/urls/"+key, root, `{"user_id":"bob"}`).Code)
+
+	w = do(http.MethodPatch, "/api/admin/urls/"+key, root, `{"user_id":"carol"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated models.AdminURLResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&updated))
+	assert.Equal(t, "carol", updated.UserID)
+	assert.False(t, updated.Disabled)
+
+	urls, err := shortener.GetUserURLs(ctx, "carol")
+	require.NoError(t, err)
+	assert.Len(t, urls, 1)
+}
+
+func TestTeams(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "urls_*.jsonl")
+	require.NoError(t, err)
+	file.Close()
+	fs, err := store.NewFileStore(file.Name(), models.DedupUser)
+	require.NoError(t, err)
+
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+	bearer := func(sub string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": sub,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	shortener := service.NewURLShortener(fs, service.WithDedupScope(models.DedupUser))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(jwtAuth),
+		handler.WithTeams(shortener),
+	)
+	router := h.SetupRouter()
+
+	do := func(user, method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Authorization", bearer(user))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v interface{}) {
+		t.Helper()
+		require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("alice", http.MethodPost, "/api/teams", `{"name":"  "}`).Code)
+	w := do("alice", http.MethodPost, "/api/teams", `{"name":"Marketing"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var team models.TeamResponse
+	decode(w, &team)
+	assert.Equal(t, models.TeamRoleAdmin, team.Role)
+	base := "/api/teams/" + team.ID
+
+	// посторонний не видит пространство
+	assert.Equal(t, http.StatusNotFound, do("bob", http.MethodGet, base+"/urls", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("bob", http.MethodPost, base+"/invites", `{}`).Code)
+
+	w = do("alice", http.MethodPost, base+"/invites", `{"role":"member"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var invite models.TeamInviteResponse
+	decode(w, &invite)
+
+	w = do("bob", http.MethodPost, "/api/teams/join", `{"token":"`+invite.Token+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, do("carol", http.MethodPost, "/api/teams/join", `{"token":"`+invite.Token+`"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("bob", http.MethodPost, base+"/invites", `{}`).Code)
+
+	w = do("bob", http.MethodGet, "/api/teams", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var teams []models.TeamResponse
+	decode(w, &teams)
+	require.Len(t, teams, 1)
+	assert.Equal(t, models.TeamRoleMember, teams[0].Role)
+
+	// ссылки пространства не смешиваются с личными
+	require.Equal(t, http.StatusCreated, do("alice", http.MethodPost, "/", "https://example.com/promo").Code)
+	w = do("alice", http.MethodPost, base+"/urls", `{"url":"https://example.com/promo"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var aliceLink models.ShortenResponse
+	decode(w, &aliceLink)
+	w = do("bob", http.MethodPost, base+"/urls", `{"url":"https://example.com/promo"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "self", w.Header().Get(handler.LinkOwnerHeader))
+	w = do("bob", http.MethodPost, base+"/urls", `{"url":"https://example.com/bob"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var bobLink models.ShortenResponse
+	decode(w, &bobLink)
+	aliceKey := aliceLink.Result[strings.LastIndex(aliceLink.Result, "/")+1:]
+	bobKey := bobLink.Result[strings.LastIndex(bobLink.Result, "/")+1:]
+
+	w = do("alice", http.MethodGet, "/api/user/urls", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var personal []models.UserURLsResponse
+	decode(w, &personal)
+	assert.Len(t, personal, 1)
+
+	w = do("bob", http.MethodGet, base+"/urls", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var links []models.TeamURLResponse
+	decode(w, &links)
+	require.Len(t, links, 2)
+
+	// участник меняет только свои ссылки, администратор — любые
+	assert.Equal(t, http.StatusForbidden, do("bob", http.MethodPatch, base+"/urls/"+aliceKey, `{"url":"https://example.com/x"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do("bob", http.MethodPatch, base+"/urls/"+bobKey, `{"url":"https://example.com/bob2"}`).Code)
+	assert.Equal(t, http.StatusConflict, do("alice", http.MethodPatch, base+"/urls/"+bobKey, `{"url":"https://example.com/promo"}`).Code)
+
+	require.Equal(t, http.StatusNoContent, do("bob", http.MethodDelete, base+"/urls", `["`+aliceKey+`"]`).Code)
+	w = do("alice", http.MethodGet, base+"/urls", "")
+	links = nil
+	decode(w, &links)
+	assert.Len(t, links, 2)
+
+	require.Equal(t, http.StatusNoContent, do("alice", http.MethodDelete, base+"/urls", `["`+aliceKey+`"]`).Code)
+	assert.Equal(t, http.StatusConflict, do("alice", http.MethodDelete, base+"/members/alice", "").Code)
+	require.Equal(t, http.StatusNoContent, do("alice", http.MethodDelete, base+"/members/bob", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("bob", http.MethodGet, base+"/urls", "").Code)
+
+	// состояние переживает перезапуск файлового хранилища
+	require.NoError(t, fs.Close())
+	fs, err = store.NewFileStore(file.Name(), models.DedupUser)
+	require.NoError(t, err)
+	defer fs.Close()
+	reopened := service.NewURLShortener(fs, service.WithDedupScope(models.DedupUser))
+	urls, err := reopened.GetTeamURLs(context.Background(), "alice", team.ID)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, "https://example.com/bob2", urls[0].OriginalURL)
+	_, err = reopened.GetTeamURLs(context.Background(), "bob", team.ID)
+	assert.ErrorIs(t, err, service.ErrTeamNotFound)
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	observed := store.NewObservedStore(store.NewInMemoryStore(models.DedupGlobal), m.StoreHook)
+	shortener := service.NewURLShortener(observed)
+	h := handler.NewURLHandler(shortener, "http://localhost:8080", handler.WithMetrics(m))
+	router := h.SetupRouter()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/", "https://example.com/metrics")
+	require.Equal(t, http.StatusCreated, w.Code)
+	shortURL, err := url.Parse(w.Body.String())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTemporaryRedirect, do(http.MethodGet, shortURL.Path, "").Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/missing", "").Code)
+
+	w = do(http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `shortener_http_requests_total{method="POST",route="/",status="201"} 1`)
+	assert.Contains(t, body, `shortener_http_requests_total{method="GET",route="/{shortURL}",status="307"} 1`)
+	assert.Contains(t, body, `shortener_http_request_duration_seconds_count{method="GET",route="/{shortURL}"} 2`)
+	assert.Contains(t, body, `shortener_redirects_total{result="redirect"} 1`)
+	assert.Contains(t, body, `shortener_redirects_total{result="not_found"} 1`)
+	assert.Contains(t, body, `shortener_store_operation_duration_seconds_count{operation="Save"} 1`)
+	assert.Contains(t, body, `shortener_delete_queue_depth 0`)
+	assert.Contains(t, body, "go_goroutines")
+	// ожидаемое «не найдено» не считается сбоем хранилища
+	assert.NotContains(t, body, `shortener_store_operation_errors_total{operation="GetShortURL"}`)
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), "test", 1)
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	observed := store.NewObservedStore(store.NewInMemoryStore(models.DedupGlobal), tracing.StoreHook)
+	h := handler.NewURLHandler(service.NewURLShortener(observed), "http://localhost:8080")
+	router := h.SetupRouter()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentSpanID = "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com/traced"))
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		assert.Equal(t, traceID, span.SpanContext.TraceID().String(), span.Name)
+		spans[span.Name] = span
+	}
+	require.Contains(t, spans, "POST /")
+	require.Contains(t, spans, "URLShortener.Shorten")
+	require.Contains(t, spans, "store.Save")
+
+	server := spans["POST /"]
+	assert.Equal(t, parentSpanID, server.Parent.SpanID().String())
+	assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusCreated))
+	assert.Equal(t, server.SpanContext.SpanID(), spans["URLShortener.Shorten"].Parent.SpanID())
+	assert.Equal(t, spans["URLShortener.Shorten"].SpanContext.SpanID(), spans["store.Save"].Parent.SpanID())
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.GroupShorten: {Rate: 1.0 / 60, Burst: 2},
+	}, nil)
+	require.NoError(t, err)
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost:8080",
+		handler.WithRateLimiter(limiter))
+	router := h.SetupRouter()
+
+	shorten := func(remote string, n int) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf("https://example.com/%d", n)))
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := shorten("192.0.2.1:1000", 1)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, http.StatusCreated, shorten("192.0.2.1:1000", 2).Code)
+
+	w = shorten("192.0.2.1:1000", 3)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// у другого адреса своя корзина, а группы без лимита не ограничиваются
+	assert.Equal(t, http.StatusCreated, shorten("192.0.2.2:1000", 4).Code)
+	r := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	r.RemoteAddr = "192.0.2.1:1000"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.NotEqual(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestDefaultRateLimits(t *testing.T) {
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	limiter, err := newRateLimiter(cfg)
+	require.NoError(t, err)
+	for _, group := range []string{ratelimit.GroupShorten, ratelimit.GroupBatch, ratelimit.GroupRedirect, ratelimit.GroupAPI, ratelimit.GroupAuth} {
+		assert.Equal(t, ratelimit.Limit{}, limiter.Limit(group), group)
+	}
+
+	router := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), cfg.BaseURL,
+		handler.WithRateLimiter(limiter)).SetupRouter()
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf("https://example.com/%d", i))))
+		require.Equal(t, http.StatusCreated, w.Code, "request %d", i)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	prevLog := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = prevLog })
+
+	mockShortener := new(MockShortener)
+	mockShortener.On("GetUserURLs", mock.Anything, mock.Anything).Return([]models.UserURLsResponse(nil), errors.New("boom"))
+	router := handler.NewURLHandler(mockShortener, "http://localhost:8080").SetupRouter()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	r.Header.Set(middleware.RequestIDHeader, "req-42")
+	r.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get(middleware.RequestIDHeader))
+
+	failure := logs.FilterMessage("Failed to get user URLs").All()
+	require.Len(t, failure, 1)
+	assert.Equal(t, "req-42", failure[0].ContextMap()[logger.RequestIDField])
+
+	access := logs.FilterMessage("HTTP request").All()
+	require.Len(t, access, 1)
+	fields := access[0].ContextMap()
+	assert.Equal(t, "req-42", fields[logger.RequestIDField])
+	assert.Equal(t, int64(http.StatusInternalServerError), fields["status"])
+	assert.Equal(t, "/api/user/urls", fields["route"])
+	assert.Equal(t, "192.0.2.1:1234", fields["remote_addr"])
+	assert.NotEmpty(t, fields["user_id"])
+
+	// недопустимый идентификатор клиента заменяется сгенерированным
+	r = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	r.Header.Set(middleware.RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	_, err := uuid.Parse(w.Header().Get(middleware.RequestIDHeader))
+	assert.NoError(t, err)
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+	token := func(sub string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": sub,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(jwtAuth),
+		handler.WithAdmin(shortener, auth.NewAdminPolicy([]string{"root"}, "").IsAdmin),
+		handler.WithLogLevel(level),
+	)
+	router := h.SetupRouter()
+
+	do := func(method, authorization, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/admin/log-level", strings.NewReader(body))
+		r.Header.Set("Authorization", authorization)
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, token("alice"), `{"level":"debug"}`).Code)
+	assert.Equal(t, zap.InfoLevel, level.Level())
+
+	w := do(http.MethodPut, token("root"), `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	assert.Equal(t, zap.DebugLevel, level.Level())
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, token("root"), `{"level":"loud"}`).Code)
+	w = do(http.MethodGet, token("root"), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+}
+
+func TestHardening(t *testing.T) {
+	mockShortener := new(MockShortener)
+	mockShortener.On("GetUserURLs", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("boom")
+	}).Return([]models.UserURLsResponse(nil), nil)
+	mockShortener.On("GetOriginalURL", mock.Anything, "slow").Run(func(mock.Arguments) {
+		time.Sleep(200 * time.Millisecond)
+	}).Return(models.UserURLsResponse{OriginalURL: "https://example.com"}, true)
+
+	h := handler.NewURLHandler(mockShortener, "http://localhost:8080",
+		handler.WithTimeouts(time.Minute, map[string]time.Duration{ratelimit.GroupRedirect: 20 * time.Millisecond}),
+		handler.WithBodyLimits(32, 64),
+	)
+	router := h.SetupRouter()
+
+	do := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/user/urls", "", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodGet, "/slow", "", "").Code)
+
+	long := "https://example.com/" + strings.Repeat("a", 64)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(http.MethodPost, "/", "", long).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		do(http.MethodPost, "/api/shorten", "application/json", `{"url":"`+long+`"}`).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		do(http.MethodPost, "/api/shorten/batch", "application/json", `[{"correlation_id":"1","original_url":"`+long+`"}]`).Code)
+
+	// без Content-Length лимит срабатывает при чтении тела
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader(long)))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestSecurityMiddleware(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "bearer-user",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	require.NoError(t, err)
+
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost:8080",
+		handler.WithAuthenticators(jwtAuth),
+		handler.WithCORS(middleware.CORSOptions{
+			AllowedOrigins:   []string{"chrome-extension://abc", " https://app.example.com"},
+			AllowedMethods:   []string{"GET", "POST", "DELETE"},
+			AllowedHeaders:   []string{"Content-Type", "X-CSRF-Token"},
+			ExposedHeaders:   []string{middleware.CSRFHeader},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		}),
+		handler.WithCSRF(),
+		handler.WithHSTS(24*time.Hour),
+	)
+	router := h.SetupRouter()
+
+	do := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		w := do(httptest.NewRequest(http.MethodGet, "/ping", nil))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "max-age=86400; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("cors", func(t *testing.T) {
+		preflight := func(origin, method string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodOptions, "/api/shorten", nil)
+			r.Header.Set("Origin", origin)
+			r.Header.Set("Access-Control-Request-Method", method)
+			return do(r)
+		}
+		w := preflight("https://app.example.com", http.MethodPost)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, POST, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+		assert.Empty(t, preflight("https://evil.example", http.MethodPost).Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, preflight("https://app.example.com", http.MethodPut).Header().Get("Access-Control-Allow-Origin"))
+
+		r := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		r.Header.Set("Origin", "chrome-extension://abc")
+		w = do(r)
+		assert.Equal(t, "chrome-extension://abc", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, middleware.CSRFHeader, w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("csrf", func(t *testing.T) {
+		shorten := func(cookies []*http.Cookie, csrf, authorization string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com/csrf"))
+			for _, c := range cookies {
+				r.AddCookie(c)
+			}
+			if csrf != "" {
+				r.Header.Set(middleware.CSRFHeader, csrf)
+			}
+			if authorization != "" {
+				r.Header.Set("Authorization", authorization)
+			}
+			return do(r)
+		}
+
+		// первый запрос без cookie не требует токена и выдаёт его
+		w := shorten(nil, "", "")
+		require.Equal(t, http.StatusCreated, w.Code)
+		cookies := w.Result().Cookies()
+		var csrfToken string
+		for _, c := range cookies {
+			if c.Name == middleware.CSRFCookieName {
+				csrfToken = c.Value
+				assert.False(t, c.HttpOnly)
+			}
+		}
+		require.NotEmpty(t, csrfToken)
+		assert.Equal(t, csrfToken, w.Header().Get(middleware.CSRFHeader))
+
+		assert.Equal(t, http.StatusForbidden, shorten(cookies, "", "").Code)
+		assert.Equal(t, http.StatusForbidden, shorten(cookies, "forged", "").Code)
+		assert.Equal(t, http.StatusConflict, shorten(cookies, csrfToken, "").Code)
+
+		// токен должен быть подписан сервером, а не просто совпадать с cookie
+		forged := []*http.Cookie{{Name: middleware.CSRFCookieName, Value: "forged"}}
+		for _, c := range cookies {
+			if c.Name == auth.CookieName {
+				forged = append(forged, c)
+			}
+		}
+		assert.Equal(t, http.StatusForbidden, shorten(forged, "forged", "").Code)
+
+		// запросы с bearer-токеном не зависят от cookie браузера
+		assert.Equal(t, http.StatusConflict, shorten(nil, "", "Bearer "+token).Code)
+	})
+}
+
+func TestDefaultConfigLegacyCookieClient(t *testing.T) {
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), cfg.BaseURL, securityOptions(cfg)...)
+	router := h.SetupRouter()
+
+	do := func(method, target string, body string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if strings.HasPrefix(target, "/api/") {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// клиент, не знающий о CSRF-токенах, повторяет только cookie user_id
+	w := do(http.MethodPost, "/", "https://example.com/legacy", nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var cookies []*http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == auth.CookieName {
+			cookies = append(cookies, c)
+		}
+	}
+	require.NotEmpty(t, cookies)
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/", "https://example.com/legacy-2", cookies).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"https://example.com/legacy-3"}`, cookies).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten/batch",
+		`[{"correlation_id":"1","original_url":"https://example.com/legacy-4"}]`, cookies).Code)
+	assert.Equal(t, http.StatusAccepted, do(http.MethodDelete, "/api/user/urls", `["missing"]`, cookies).Code)
+}
+
+func TestSeparateAdmin(t *testing.T) {
+	m := metrics.New()
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost:8080",
+		handler.WithMetrics(m), handler.WithSeparateAdmin())
+	public, admin := h.SetupRouter(), h.SetupAdminRouter()
+
+	get := func(router http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	assert.NotEqual(t, http.StatusOK, get(public, "/ping").Code)
+	assert.NotEqual(t, http.StatusOK, get(public, "/metrics").Code)
+	assert.NotEqual(t, http.StatusOK, get(public, "/internal/stats").Code)
+
+	assert.Equal(t, http.StatusOK, get(admin, "/ping").Code)
+	assert.Equal(t, http.StatusOK, get(admin, "/metrics").Code)
+	w := get(admin, "/internal/stats")
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, true, stats["store_ready"])
+	assert.Greater(t, stats["goroutines"], float64(0))
+	assert.Equal(t, http.StatusNotFound, get(admin, "/api/user/urls").Code, "public routes are not served on the admin listener")
+
+	// без отдельного слушателя служебные маршруты остаются на основном
+	h = handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost:8080", handler.WithMetrics(m))
+	assert.Equal(t, http.StatusOK, get(h.SetupRouter(), "/ping").Code)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	fileStore, err := store.NewFileStore(path, models.DedupGlobal)
+	require.NoError(t, err)
+	defer fileStore.Close()
+
+	cfg := &config.Config{File: path, HealthCheckTimeout: config.Duration(time.Second), HealthMinFreeBytes: 1}
+	checker := newHealthChecker(cfg, fileStore)
+	h := handler.NewURLHandler(service.NewURLShortener(fileStore), "http://localhost:8080", handler.WithHealth(checker))
+	router := h.SetupRouter()
+
+	get := func(target string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), target)
+		return w.Code, body
+	}
+
+	code, _ := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	code, body := get("/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "up", body["status"])
+	components := body["components"].([]any)
+	require.Len(t, components, 2)
+	assert.Equal(t, "store", components[0].(map[string]any)["name"])
+	assert.Equal(t, "disk_space", components[1].(map[string]any)["name"])
+
+	// файл данных удалён из-под работающего хранилища
+	require.NoError(t, os.Remove(path))
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body["error"], "store:")
+	code, body = get("/health")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	storeStatus := body["components"].([]any)[0].(map[string]any)
+	assert.Equal(t, "down", storeStatus["status"])
+	assert.NotEmpty(t, storeStatus["last_error"])
+
+	// liveness не зависит от хранилища
+	code, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	f.Close()
+	checker.SetDraining()
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.ErrDraining.Error(), body["error"])
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	m := metrics.New()
+	info := buildinfo.Info{Version: "v1.0.0", Commit: "abc123", Date: "2026-01-02", GoVersion: "go1.24.1",
+		Dependencies: []buildinfo.Dependency{{Path: "github.com/go-chi/chi", Version: "v1.5.5"}}}
+	m.SetBuildInfo(info, store.BackendMemory, buildinfo.StartTime)
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), "http://localhost:8080",
+		handler.WithMetrics(m), handler.WithBuildInfo(info, store.BackendMemory))
+	h.SetConfigFingerprint("0123456789abcdef")
+	router := h.SetupRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "v1.0.0", resp["version"])
+	assert.Equal(t, "abc123", resp["commit"])
+	assert.Equal(t, "go1.24.1", resp["go_version"])
+	assert.Equal(t, "memory", resp["store_backend"])
+	assert.Equal(t, "0123456789abcdef", resp["config_fingerprint"])
+	assert.Contains(t, resp, "uptime_seconds")
+	assert.Len(t, resp["dependencies"], 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `shortener_build_info{build_date="2026-01-02",commit="abc123",go_version="go1.24.1",store_backend="memory",version="v1.0.0"} 1`)
+	assert.Contains(t, body, `shortener_config_info{fingerprint="0123456789abcdef"} 1`)
+	assert.Contains(t, body, "shortener_start_time_seconds")
+}
+
+func TestPprof(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+	token := func(sub string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": sub,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	shortener := service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal))
+	h := handler.NewURLHandler(shortener, "http://localhost:8080",
+		handler.WithAuthenticators(jwtAuth),
+		handler.WithAdmin(shortener, auth.NewAdminPolicy([]string{"root"}, "").IsAdmin),
+		handler.WithSeparateAdmin(),
+		handler.WithProfiling(),
+	)
+	admin := h.SetupAdminRouter()
+
+	get := func(target, authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, get("/debug/pprof/", "").Code)
+	assert.Equal(t, http.StatusForbidden, get("/debug/pprof/heap", token("alice")).Code)
+
+	w := get("/debug/pprof/", token("root"))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+	for _, target := range []string{
+		"/debug/pprof/heap?debug=1",
+		"/debug/pprof/goroutine?debug=1",
+		"/debug/pprof/cmdline",
+		"/debug/pprof/profile?seconds=1",
+		"/debug/pprof/trace?seconds=0.1",
+	} {
+		assert.Equal(t, http.StatusOK, get(target, token("root")).Code, target)
+	}
+	assert.Equal(t, http.StatusOK, get("/ping", "").Code, "other admin routes stay unauthenticated")
+
+	// маршруты профилирования не попадают на основной слушатель
+	r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	r.Header.Set("Authorization", token("root"))
+	w = httptest.NewRecorder()
+	h.SetupRouter().ServeHTTP(w, r)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+	"github.com/AlexeySalamakhin/URLShortener/internal/handler"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
+)
+
+// liveSettings — настройки (имена как в файле конфигурации), которые применяются без
+// перезапуска. Изменения остальных только записываются в лог.
+var liveSettings = map[string]bool{
+	"log_level":           true,
+	"base_url":            true,
+	"rate_limit_shorten":  true,
+	"rate_limit_batch":    true,
+	"rate_limit_redirect": true,
+	"rate_limit_api":      true,
+	"rate_limit_auth":     true,
+}
+
+// reloader применяет к работающему серверу перечитанную конфигурацию.
+type reloader struct {
+	// mu защищает current: список файлов читает наблюдатель за файлами
+	mu sync.Mutex
+	// current — действующая конфигурация: настройки из liveSettings взяты
+	// из последней принятой, остальные — те, с которыми сервер запущен
+	current *config.Config
+	handler *handler.URLHandler
+	limiter *ratelimit.Limiter
+}
+
+// reload перечитывает конфигурацию и применяет изменения настроек из
+// liveSettings. Если новая конфигурация некорректна, ничего не меняется.
+func (rl *reloader) reload() error {
+	next, err := rl.current.Reload()
+	if err != nil {
+		return err
+	}
+
+	// сначала проверяем все значения, чтобы не применить конфигурацию частично
+	level, err := zapcore.ParseLevel(next.LogLevel)
+	if err != nil {
+		return fmt.Errorf("log_level: %w", err)
+	}
+	limits, err := rateLimits(next)
+	if err != nil {
+		return err
+	}
+	if u, err := url.Parse(next.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("base_url: invalid URL %q", next.BaseURL)
+	}
+
+	// сравнение с действующей конфигурацией, а не с прошлой перечитанной:
+	// так о настройках, ждущих перезапуска, предупреждает каждое перечитывание
+	changed := config.Changed(rl.current, next)
+	applied := rl.current.Merge(next, liveSettings)
+	logger.Level().SetLevel(level)
+	rl.limiter.SetLimits(limits)
+	rl.handler.SetBaseURL(next.BaseURL)
+	rl.handler.SetConfigFingerprint(applied.Fingerprint())
+	rl.mu.Lock()
+	rl.current = applied
+	rl.mu.Unlock()
+
+	var live, restart []string
+	for _, name := range changed {
+		if liveSettings[name] {
+			live = append(live, name)
+		} else {
+			restart = append(restart, name)
+		}
+	}
+	logger.Log.Info("Конфигурация перечитана", zap.Strings("applied", live))
+	if len(restart) > 0 {
+		logger.Log.Warn("Изменённые настройки вступят в силу после перезапуска", zap.Strings("settings", restart))
+	}
+	return nil
+}
+
+// files возвращает файлы действующей конфигурации.
+func (rl *reloader) files() []string {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.current.Files()
+}
+
+// watchFiles отправляет в changes сигнал при каждом изменении времени
+// модификации или размера любого из файлов, проверяя их раз в interval.
+// Список файлов запрашивается у paths при каждой проверке, поэтому файлы,
+// включённые в конфигурацию при перечитывании, тоже отслеживаются; их
+// первое появление в списке изменением не считается.
+func watchFiles(ctx context.Context, paths func() []string, interval time.Duration, changes chan<- struct{}) {
+	type fileState struct {
+		modTime time.Time
+		size    int64
+	}
+	stat := func() map[string]fileState {
+		states := make(map[string]fileState)
+		for _, path := range paths() {
+			info, err := os.Stat(path)
+			if err != nil {
+				states[path] = fileState{size: -1}
+				continue
+			}
+			states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return states
+	}
+
+	states := stat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			next := stat()
+			changed := false
+			for path, state := range next {
+				prev, ok := states[path]
+				if ok && (!prev.modTime.Equal(state.modTime) || prev.size != state.size) {
+					changed = true
+				}
+			}
+			states = next
+			if !changed {
+				continue
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+	"github.com/AlexeySalamakhin/URLShortener/internal/handler"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
+	"github.com/AlexeySalamakhin/URLShortener/internal/service"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+)
+
+func TestReload(t *testing.T) {
+	prevLevel := logger.Level().Level()
+	t.Cleanup(func() { logger.Level().SetLevel(prevLevel) })
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	write(`{"base_url": "http://localhost:8080"}`)
+
+	current, err := config.Load([]string{"-c", path})
+	require.NoError(t, err)
+	limiter, err := newRateLimiter(current)
+	require.NoError(t, err)
+	h := handler.NewURLHandler(service.NewURLShortener(store.NewInMemoryStore(models.DedupGlobal)), current.BaseURL)
+	rl := &reloader{current: current, handler: h, limiter: limiter}
+
+	write(`{
+		"base_url": "https://sho.rt",
+		"log_level": "debug",
+		"rate_limit_shorten": "5/s",
+		"server_address": ":9090"
+	}`)
+	require.NoError(t, rl.reload())
+	assert.Equal(t, "https://sho.rt", h.BaseURL())
+	assert.Equal(t, zapcore.DebugLevel, logger.Level().Level())
+	assert.Equal(t, ratelimit.Limit{Rate: 5, Burst: 5}, limiter.Limit(ratelimit.GroupShorten))
+	// настройки, требующие перезапуска, в действующую конфигурацию не попадают
+	assert.Equal(t, current.ServerAddr, rl.current.ServerAddr)
+	assert.Equal(t, "https://sho.rt", rl.current.BaseURL)
+	next, err := current.Reload()
+	require.NoError(t, err)
+	assert.NotEqual(t, next.Fingerprint(), rl.current.Fingerprint())
+	// и остаются в разнице при каждом следующем перечитывании
+	require.NoError(t, rl.reload())
+	assert.Equal(t, []string{"server_address"}, config.Changed(rl.current, next))
+
+	for _, bad := range []string{
+		`{"base_url": `,
+		`{"base_url": "https://bad.example", "log_level": "loud"}`,
+		`{"base_url": "https://bad.example", "rate_limit_batch": "many"}`,
+		`{"base_url": "not a url"}`,
+	} {
+		write(bad)
+		assert.Error(t, rl.reload(), bad)
+		assert.Equal(t, "https://sho.rt", h.BaseURL(), bad)
+		assert.Equal(t, zapcore.DebugLevel, logger.Level().Level(), bad)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	secret := filepath.Join(dir, "dsn")
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	require.NoError(t, os.WriteFile(secret, []byte("postgres://db"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 1)
+	var mu sync.Mutex
+	paths := []string{path, secret}
+	go watchFiles(ctx, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(paths)
+	}, 10*time.Millisecond, changes)
+
+	// файл, включённый в конфигурацию позже, тоже отслеживается
+	include := filepath.Join(dir, "limits.json")
+	require.NoError(t, os.WriteFile(include, []byte(`{}`), 0o600))
+	mu.Lock()
+	paths = append(paths, include)
+	mu.Unlock()
+
+	for _, change := range []struct{ path, content string }{
+		{path, `{"log_level": "warn"}`},
+		{secret, "postgres://other-db"},
+		{include, `{"rate_limit_api": "10/s"}`},
+	} {
+		time.Sleep(30 * time.Millisecond)
+		require.NoError(t, os.WriteFile(change.path, []byte(change.content), 0o600))
+		select {
+		case <-changes:
+		case <-time.After(time.Second):
+			t.Fatalf("change of %s not detected", change.path)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/config"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tlsconfig"
+)
+
+// tlsSetup — настройки HTTPS-сервера.
+type tlsSetup struct {
+	// config — настройки TLS для http.Server
+	config *tls.Config
+	// reloader перечитывает сертификат из файлов; nil при выпуске через ACME
+	reloader *tlsconfig.Reloader
+	// redirect — обработчик HTTP-сервера перенаправления на HTTPS
+	redirect http.Handler
+}
+
+// newTLSSetup собирает настройки TLS. Если заданы файлы сертификата и ключа,
+// используется статический сертификат, иначе сертификат выпускается через
+// ACME (autocert), и сервер перенаправления заодно отвечает на проверки
+// HTTP-01.
+func newTLSSetup(cfg *config.Config) (*tlsSetup, error) {
+	opts := tlsconfig.Options{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   cfg.TLSClientAuth,
+		MinVersion:   cfg.TLSMinVersion,
+		MaxVersion:   cfg.TLSMaxVersion,
+		CipherSuites: strings.Split(cfg.TLSCipherSuites, ","),
+	}
+	redirect := tlsconfig.RedirectHandler(cfg.ServerAddr)
+
+	if cfg.TLSCertFile != "" {
+		reloader, err := tlsconfig.NewReloader(opts)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg, err := reloader.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		return &tlsSetup{config: tlsCfg, reloader: reloader, redirect: redirect}, nil
+	}
+
+	manager := &autocert.Manager{
+		Cache:  autocert.DirCache(".autocert-cache"),
+		Prompt: autocert.AcceptTOS,
+	}
+	tlsCfg := manager.TLSConfig()
+	if err := opts.Apply(tlsCfg); err != nil {
+		return nil, err
+	}
+	if cfg.TLSClientCAFile != "" {
+		pool, err := tlsconfig.LoadCertPool(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.ClientCAs = pool
+	}
+	return &tlsSetup{config: tlsCfg, redirect: manager.HTTPHandler(redirect)}, nil
+}
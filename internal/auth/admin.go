@@ -0,0 +1,33 @@
+package auth
+
+import "strings"
+
+// DefaultAdminScope — scope токена, дающий права администратора по умолчанию.
+const DefaultAdminScope = "admin"
+
+// AdminPolicy определяет, кто является администратором: пользователи
+// с перечисленными идентификаторами и владельцы токенов со scope администратора.
+type AdminPolicy struct {
+	userIDs map[string]bool
+	scope   string
+}
+
+// NewAdminPolicy создаёт политику администраторов. Пустой scope отключает
+// назначение прав через токены.
+func NewAdminPolicy(userIDs []string, scope string) *AdminPolicy {
+	p := &AdminPolicy{userIDs: make(map[string]bool, len(userIDs)), scope: scope}
+	for _, id := range userIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			p.userIDs[id] = true
+		}
+	}
+	return p
+}
+
+// IsAdmin сообщает, есть ли у пользователя права администратора.
+func (p *AdminPolicy) IsAdmin(identity Identity) bool {
+	if identity.UserID == "" {
+		return false
+	}
+	return p.userIDs[identity.UserID] || (p.scope != "" && identity.HasScope(p.scope))
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MethodAPIKey — аутентификация персональным API-ключом.
+const MethodAPIKey = "api_key"
+
+// APIKeyHeader — заголовок запроса с персональным API-ключом.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix — префикс, по которому API-ключи сервиса легко опознать
+// (например, сканерами секретов в репозиториях).
+const apiKeyPrefix = "usk_"
+
+// apiKeyDisplayLen — длина начала ключа, которая хранится открыто для отображения.
+const apiKeyDisplayLen = len(apiKeyPrefix) + 6
+
+// GenerateAPIKey генерирует новый API-ключ и возвращает его вместе
+// с отображаемым префиксом.
+func GenerateAPIKey() (key string, prefix string) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyDisplayLen]
+}
+
+// HashAPIKey возвращает хэш API-ключа, под которым он хранится.
+// Ключи содержат 256 бит случайных данных, поэтому соль и медленный хэш не нужны.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyLookup возвращает идентификатор владельца API-ключа или ошибку,
+// оборачивающую ErrInvalidCredentials, если ключ неизвестен.
+type APIKeyLookup func(ctx context.Context, key string) (string, error)
+
+// APIKeyAuthenticator аутентифицирует запросы по заголовку X-API-Key.
+type APIKeyAuthenticator struct {
+	lookup APIKeyLookup
+}
+
+// NewAPIKeyAuthenticator создаёт аутентификатор API-ключей.
+func NewAPIKeyAuthenticator(lookup APIKeyLookup) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{lookup: lookup}
+}
+
+// Authenticate проверяет API-ключ из заголовка X-API-Key.
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if key == "" {
+		return Identity{}, ErrNoCredentials
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return Identity{}, fmt.Errorf("%w: malformed API key", ErrInvalidCredentials)
+	}
+
+	userID, err := a.lookup(r.Context(), key)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: userID, Method: MethodAPIKey}, nil
+}
@@ -1,28 +1,150 @@
 package auth
 
 import (
+	"bufio"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
-	"encoding/hex"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-const secretKey = "secret_key"
+// CookieName — имя cookie с идентификатором пользователя.
+const CookieName = "user_id"
 
-// GenerateCookie создаёт подписанную cookie с идентификатором пользователя.
-func GenerateCookie(userID string) *http.Cookie {
+// minSecretLen — минимальная длина секрета ключа подписи в байтах.
+const minSecretLen = 16
 
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(userID))
-	signature := hex.EncodeToString(h.Sum(nil))
+// ErrNoKeys возвращается, если не передано ни одного ключа подписи.
+var ErrNoKeys = errors.New("no cookie signing keys")
 
-	return &http.Cookie{
-		Name:  "user_id",
-		Value: userID + "|" + signature,
+// Key — ключ подписи cookie и его идентификатор, который записывается в cookie
+// и позволяет проверять подписи, сделанные предыдущими ключами при ротации.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// CookieOptions — атрибуты выдаваемых cookie.
+type CookieOptions struct {
+	// Secure — передавать cookie только по HTTPS.
+	Secure bool
+	// SameSite — политика отправки cookie в межсайтовых запросах.
+	SameSite http.SameSite
+	// MaxAge — время жизни cookie; ноль означает сессионную cookie.
+	MaxAge time.Duration
+}
+
+// CookieSigner подписывает cookie с идентификатором пользователя активным ключом
+// и проверяет подписи любым из известных ключей.
+type CookieSigner struct {
+	keys   map[string][]byte
+	active Key
+	opts   CookieOptions
+}
+
+// NewCookieSigner создаёт подписчик cookie. Ключ activeID используется для
+// подписи новых cookie; если он пуст, активным становится первый ключ.
+func NewCookieSigner(keys []Key, activeID string, opts CookieOptions) (*CookieSigner, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if activeID == "" {
+		activeID = keys[0].ID
+	}
+	if opts.SameSite == http.SameSiteNoneMode && !opts.Secure {
+		return nil, errors.New("SameSite=None cookies require the Secure attribute")
+	}
+
+	s := &CookieSigner{keys: make(map[string][]byte, len(keys)), opts: opts}
+	for _, k := range keys {
+		if err := validateKey(k); err != nil {
+			return nil, err
+		}
+		if _, dup := s.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate cookie key id %q", k.ID)
+		}
+		s.keys[k.ID] = k.Secret
+		if k.ID == activeID {
+			s.active = k
+		}
+	}
+	if s.active.ID == "" {
+		return nil, fmt.Errorf("active cookie key %q not found", activeID)
+	}
+	return s, nil
+}
+
+// GenerateCookie создаёт подписанную активным ключом cookie с идентификатором пользователя.
+func (s *CookieSigner) GenerateCookie(userID string) *http.Cookie {
+	return s.Cookie(CookieName, s.Sign(CookieName, userID), s.opts.MaxAge)
+}
+
+// Sign подписывает значение активным ключом. Назначение purpose входит
+// в подпись, поэтому значение, подписанное для одной cookie, нельзя
+// подставить в другую.
+func (s *CookieSigner) Sign(purpose, value string) string {
+	return value + "|" + s.active.ID + "|" + sign(s.active.Secret, purpose, value)
+}
+
+// Verify проверяет подпись значения, созданного Sign, и возвращает исходное значение.
+// Флаг rotate равен true, если значение подписано неактивным ключом.
+func (s *CookieSigner) Verify(purpose, signed string) (value string, rotate bool, ok bool) {
+	// значение может содержать "|", поэтому разбираем справа
+	rest, signature, found := cutLast(signed, "|")
+	if !found {
+		return "", false, false
+	}
+	value, keyID, found := cutLast(rest, "|")
+	if !found {
+		return "", false, false
+	}
+	secret, found := s.keys[keyID]
+	if !found || value == "" {
+		return "", false, false
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, purpose, value))) {
+		return "", false, false
+	}
+	return value, keyID != s.active.ID, true
+}
+
+// Cookie создаёт HttpOnly-cookie с атрибутами Secure и SameSite из настроек.
+// Время жизни maxAge, равное нулю, означает сессионную cookie,
+// а отрицательное — удаление cookie.
+func (s *CookieSigner) Cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Secure:   s.opts.Secure,
+		HttpOnly: true,
+		SameSite: s.opts.SameSite,
 	}
+	switch {
+	case maxAge > 0:
+		cookie.MaxAge = int(maxAge.Seconds())
+		cookie.Expires = time.Now().Add(maxAge)
+	case maxAge < 0:
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// ValidateCookie проверяет подпись cookie и возвращает идентификатор пользователя.
+// Флаг rotate равен true, если cookie подписана неактивным ключом и её следует перевыпустить.
+func (s *CookieSigner) ValidateCookie(cookie *http.Cookie) (userID string, rotate bool, ok bool) {
+	if cookie == nil {
+		return "", false, false
+	}
+	return s.Verify(CookieName, cookie.Value)
 }
 
 // GenerateUserID генерирует и возвращает новый уникальный идентификатор пользователя.
@@ -30,28 +152,106 @@ func GenerateUserID() string {
 	return uuid.New().String()
 }
 
-// GetUserID извлекает идентификатор пользователя из значения cookie.
-func GetUserID(cookie *http.Cookie) string {
-	parts := strings.Split(cookie.Value, "|")
-	return parts[0]
+// NewRandomKey генерирует случайный ключ подписи. Такие ключи не переживают
+// перезапуск сервиса и подходят только для разработки и тестов.
+func NewRandomKey() Key {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return Key{ID: "ephemeral", Secret: secret}
+}
+
+// ParseKeys разбирает список ключей в формате "id1:secret1,id2:secret2".
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		k, err := parseKey(item)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
 }
 
-// ValidateCookie проверяет подпись cookie и возвращает её валидность.
-func ValidateCookie(cookie *http.Cookie) bool {
-	if cookie == nil {
-		return false
+// LoadKeysFile читает ключи из файла: по одному "id:secret" на строку,
+// пустые строки и строки, начинающиеся с "#", пропускаются.
+func LoadKeysFile(path string) ([]Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []Key
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, err := parseKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, k)
 	}
-	parts := strings.Split(cookie.Value, "|")
-	if len(parts) != 2 {
-		return false
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
+	return keys, nil
+}
+
+func parseKey(s string) (Key, error) {
+	id, secret, found := strings.Cut(s, ":")
+	if !found {
+		return Key{}, fmt.Errorf("cookie key must be in id:secret format")
+	}
+	k := Key{ID: strings.TrimSpace(id), Secret: []byte(strings.TrimSpace(secret))}
+	return k, validateKey(k)
+}
+
+func validateKey(k Key) error {
+	if k.ID == "" || strings.ContainsAny(k.ID, "|:, ") {
+		return fmt.Errorf("invalid cookie key id %q", k.ID)
+	}
+	if len(k.Secret) < minSecretLen {
+		return fmt.Errorf("cookie key %q: secret must be at least %d bytes", k.ID, minSecretLen)
+	}
+	return nil
+}
 
-	userID := parts[0]
-	signature := parts[1]
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
 
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(userID))
-	expectedSignature := hex.EncodeToString(h.Sum(nil))
+func sign(secret []byte, purpose, value string) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(purpose))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
 
-	return signature == expectedSignature
+// ParseSameSite разбирает значение атрибута SameSite: lax, strict или none.
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown SameSite mode %q", s)
+	}
 }
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieSigner(t *testing.T) {
+	oldKey := Key{ID: "old", Secret: []byte("old-secret-0123456789")}
+	newKey := Key{ID: "new", Secret: []byte("new-secret-0123456789")}
+
+	oldSigner, err := NewCookieSigner([]Key{oldKey}, "", CookieOptions{})
+	require.NoError(t, err)
+	signer, err := NewCookieSigner([]Key{oldKey, newKey}, "new", CookieOptions{
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   time.Hour,
+	})
+	require.NoError(t, err)
+
+	t.Run("attributes", func(t *testing.T) {
+		c := signer.GenerateCookie("user-1")
+		assert.True(t, c.Secure)
+		assert.True(t, c.HttpOnly)
+		assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+		assert.Equal(t, 3600, c.MaxAge)
+	})
+
+	t.Run("active key", func(t *testing.T) {
+		userID, rotate, ok := signer.ValidateCookie(signer.GenerateCookie("user-1"))
+		assert.True(t, ok)
+		assert.False(t, rotate)
+		assert.Equal(t, "user-1", userID)
+	})
+
+	t.Run("rotated key", func(t *testing.T) {
+		userID, rotate, ok := signer.ValidateCookie(oldSigner.GenerateCookie("auth0|user-2"))
+		assert.True(t, ok)
+		assert.True(t, rotate)
+		assert.Equal(t, "auth0|user-2", userID)
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"user-1",
+			"user-1|secret_key",
+			"user-1|new|bad-signature",
+			"user-1|unknown|" + sign(newKey.Secret, CookieName, "user-1"),
+			"user-2|new|" + sign(newKey.Secret, CookieName, "user-1"),
+			signer.Sign("other-purpose", "user-1"),
+		} {
+			_, _, ok := signer.ValidateCookie(&http.Cookie{Name: CookieName, Value: value})
+			assert.False(t, ok, value)
+		}
+	})
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("a:0123456789abcdef, b:fedcba9876543210")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "b", keys[1].ID)
+
+	_, err = ParseKeys("a:short")
+	assert.Error(t, err)
+	_, err = ParseKeys("no-separator")
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// Способы аутентификации, которыми была установлена личность пользователя.
+const (
+	MethodCookie = "cookie"
+	MethodJWT    = "jwt"
+)
+
+var (
+	// ErrNoCredentials возвращается аутентификатором, если в запросе нет
+	// учётных данных, которые он умеет проверять.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials возвращается, если учётные данные присутствуют, но неверны.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity — личность аутентифицированного пользователя.
+type Identity struct {
+	// UserID — идентификатор пользователя.
+	UserID string
+	// Scopes — выданные пользователю права (например, из claim scope токена).
+	Scopes []string
+	// Method — способ аутентификации.
+	Method string
+}
+
+// HasScope сообщает, выдано ли пользователю право scope.
+func (i Identity) HasScope(scope string) bool {
+	return slices.Contains(i.Scopes, scope)
+}
+
+// Authenticator извлекает личность пользователя из запроса. Если в запросе
+// нет подходящих учётных данных, возвращается ErrNoCredentials.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+// Challenger — аутентификатор, который при отказе сообщает клиенту схему
+// аутентификации в заголовке WWW-Authenticate.
+type Challenger interface {
+	Challenge() string
+}
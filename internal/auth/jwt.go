@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig — параметры проверки bearer-токенов.
+type JWTConfig struct {
+	// HMACSecret — секрет для токенов, подписанных HS256.
+	HMACSecret []byte
+	// RSAPublicKey — открытый ключ для токенов, подписанных RS256.
+	RSAPublicKey *rsa.PublicKey
+	// Issuer — ожидаемое значение claim iss; пустое значение не проверяется.
+	Issuer string
+	// Audience — ожидаемое значение claim aud; пустое значение не проверяется.
+	Audience string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf.
+	Leeway time.Duration
+}
+
+// jwtClaims — claims токена, которые использует сервис.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	// Scope — права через пробел (RFC 8693).
+	Scope string `json:"scope,omitempty"`
+	// Scp — права списком, как их выдают некоторые провайдеры.
+	Scp []string `json:"scp,omitempty"`
+}
+
+// JWTAuthenticator аутентифицирует запросы по заголовку Authorization: Bearer <JWT>.
+// Идентификатором пользователя становится claim sub.
+type JWTAuthenticator struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator создаёт аутентификатор bearer-токенов. Должен быть задан
+// хотя бы один из ключей: HMACSecret или RSAPublicKey.
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	var methods []string
+	if len(cfg.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.RSAPublicKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("jwt: neither HMAC secret nor RSA public key configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &JWTAuthenticator{cfg: cfg, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Challenge возвращает значение WWW-Authenticate для отклонённого токена.
+func (a *JWTAuthenticator) Challenge() string {
+	return `Bearer error="invalid_token"`
+}
+
+// Authenticate проверяет bearer-токен из заголовка Authorization.
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return Identity{}, ErrNoCredentials
+	}
+
+	var claims jwtClaims
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &claims, a.keyFunc); err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+	return Identity{UserID: claims.Subject, Scopes: scopes, Method: MethodJWT}, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.cfg.HMACSecret, nil
+	case *jwt.SigningMethodRSA:
+		return a.cfg.RSAPublicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
+
+// LoadRSAPublicKey читает открытый RSA-ключ в формате PEM.
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(data)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	a, err := NewJWTAuthenticator(JWTConfig{
+		HMACSecret:   secret,
+		RSAPublicKey: &rsaKey.PublicKey,
+		Issuer:       "issuer",
+		Audience:     "shortener",
+	})
+	require.NoError(t, err)
+
+	claims := func(mod func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "service-1",
+			"iss":   "issuer",
+			"aud":   "shortener",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "urls:write admin",
+		}
+		if mod != nil {
+			mod(c)
+		}
+		return c
+	}
+	hs256 := func(c jwt.MapClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(secret)
+		require.NoError(t, err)
+		return s
+	}
+	rs256 := func(c jwt.MapClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodRS256, c).SignedString(rsaKey)
+		require.NoError(t, err)
+		return s
+	}
+
+	testCases := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{name: "no header", header: "", wantErr: ErrNoCredentials},
+		{name: "basic scheme", header: "Basic dXNlcjpwYXNz", wantErr: ErrNoCredentials},
+		{name: "hs256", header: "Bearer " + hs256(claims(nil))},
+		{name: "rs256", header: "Bearer " + rs256(claims(nil))},
+		{name: "expired", header: "Bearer " + hs256(claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })), wantErr: ErrInvalidCredentials},
+		{name: "no exp", header: "Bearer " + hs256(claims(func(c jwt.MapClaims) { delete(c, "exp") })), wantErr: ErrInvalidCredentials},
+		{name: "wrong audience", header: "Bearer " + hs256(claims(func(c jwt.MapClaims) { c["aud"] = "other" })), wantErr: ErrInvalidCredentials},
+		{name: "wrong issuer", header: "Bearer " + hs256(claims(func(c jwt.MapClaims) { c["iss"] = "other" })), wantErr: ErrInvalidCredentials},
+		{name: "no subject", header: "Bearer " + hs256(claims(func(c jwt.MapClaims) { delete(c, "sub") })), wantErr: ErrInvalidCredentials},
+		{name: "garbage", header: "Bearer not-a-token", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			identity, err := a.Authenticate(r)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "service-1", identity.UserID)
+			assert.Equal(t, MethodJWT, identity.Method)
+			assert.True(t, identity.HasScope("admin"))
+		})
+	}
+}
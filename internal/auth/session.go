@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// MethodSession — аутентификация сессией зарегистрированного пользователя.
+const MethodSession = "session"
+
+// SessionCookieName — имя cookie с токеном сессии.
+const SessionCookieName = "session"
+
+// GenerateSessionToken генерирует случайный токен сессии.
+func GenerateSessionToken() string {
+	return randomToken()
+}
+
+// HashSessionToken возвращает хэш токена сессии, под которым она хранится.
+func HashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SessionLookup возвращает идентификатор пользователя сессии или ошибку,
+// оборачивающую ErrInvalidCredentials, если сессия неизвестна или истекла.
+type SessionLookup func(ctx context.Context, token string) (string, error)
+
+// SessionAuthenticator аутентифицирует запросы по cookie сессии.
+type SessionAuthenticator struct {
+	lookup SessionLookup
+}
+
+// NewSessionAuthenticator создаёт аутентификатор сессий.
+func NewSessionAuthenticator(lookup SessionLookup) *SessionAuthenticator {
+	return &SessionAuthenticator{lookup: lookup}
+}
+
+// Authenticate проверяет cookie сессии. Истёкшая или отозванная сессия
+// не считается ошибкой: пользователь продолжает работу как анонимный.
+func (a *SessionAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return Identity{}, ErrNoCredentials
+	}
+
+	userID, err := a.lookup(r.Context(), cookie.Value)
+	if errors.Is(err, ErrInvalidCredentials) {
+		return Identity{}, ErrNoCredentials
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: userID, Method: MethodSession}, nil
+}
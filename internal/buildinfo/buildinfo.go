@@ -0,0 +1,75 @@
+// Package buildinfo собирает сведения о сборке сервиса: версию и коммит,
+// переданные через -ldflags, версию Go и зависимости модуля.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// NotAvailable подставляется вместо неизвестных сведений о сборке.
+const NotAvailable = "N/A"
+
+// StartTime — время запуска процесса.
+var StartTime = time.Now()
+
+// Dependency — модуль, вошедший в сборку.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replace — модуль, которым заменена зависимость директивой replace.
+	Replace string `json:"replace,omitempty"`
+}
+
+// Info — сведения о сборке.
+type Info struct {
+	Version      string       `json:"version"`
+	Commit       string       `json:"commit"`
+	Date         string       `json:"build_date"`
+	GoVersion    string       `json:"go_version"`
+	Module       string       `json:"module,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// Read возвращает сведения о сборке. version, date и commit — значения,
+// заданные через -ldflags; если они пусты, используются версия модуля и
+// данные системы контроля версий, записанные go build, а при их отсутствии —
+// NotAvailable.
+func Read(version, date, commit string) Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Module = bi.Main.Path
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+		for _, dep := range bi.Deps {
+			d := Dependency{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				d.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			info.Dependencies = append(info.Dependencies, d)
+		}
+	}
+
+	for _, field := range []*string{&info.Version, &info.Commit, &info.Date} {
+		if *field == "" {
+			*field = NotAvailable
+		}
+	}
+	return info
+}
+
+// Uptime возвращает время работы процесса.
+func Uptime() time.Duration {
+	return time.Since(StartTime)
+}
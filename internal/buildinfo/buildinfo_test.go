@@ -0,0 +1,22 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	info := Read("v1.2.3", "2026-01-02", "abc123")
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "2026-01-02", info.Date)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+
+	// в тестовом бинарнике нет ни -ldflags, ни данных VCS
+	info = Read("", "", "")
+	assert.NotEmpty(t, info.Version)
+	assert.NotEmpty(t, info.Date)
+	assert.NotEmpty(t, info.Commit)
+}
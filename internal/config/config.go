@@ -1,61 +1,329 @@
 package config
 
 import (
-	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 )
 
 // Config содержит конфигурационные параметры приложения, доступные через
-// флаги командной строки, переменные окружения и JSON-файл.
+// флаги командной строки, переменные окружения и файл конфигурации.
+// Порядок приоритета источников описан в Load. Поля с тегом secret
+// скрываются в Redacted и могут ссылаться на файл ("file:путь") или
+// переменную окружения ("env:ИМЯ").
 type Config struct {
+	// args — аргументы командной строки, из которых собрана конфигурация
+	args []string
+	// files — прочитанные файлы конфигурации и секретов
+	files []string
+
 	// ServerAddr — адрес, на котором запускается сервер (например, ":8080")
 	ServerAddr string `env:"SERVER_ADDRESS" json:"server_address"`
+	// Listen — дополнительные адреса сервера через запятую: host:port, unix:///путь или systemd:[имя]
+	Listen string `env:"LISTEN" json:"listen"`
+	// AdminAddr — адрес служебного слушателя для /ping, /metrics и /internal/stats; если задан, снаружи они недоступны
+	AdminAddr string `env:"ADMIN_ADDRESS" json:"admin_address"`
+	// UnixSocketMode — восьмеричные права на файлы Unix-сокетов, например 0660
+	UnixSocketMode string `env:"UNIX_SOCKET_MODE" json:"unix_socket_mode"`
+	// UnixSocketGroup — группа файлов Unix-сокетов (имя или gid)
+	UnixSocketGroup string `env:"UNIX_SOCKET_GROUP" json:"unix_socket_group"`
 	// BaseURL — базовый URL сервиса сокращения ссылок
 	BaseURL string `env:"BASE_URL" json:"base_url"`
 	// File — путь к файлу для хранения данных (если используется файловое хранилище)
 	File string `env:"FILE_STORAGE_PATH" json:"file_storage_path"`
 	// ConnectionString — строка подключения к базе данных (DSN)
-	ConnectionString string `env:"DATABASE_DSN" json:"database_dsn"`
+	ConnectionString string `env:"DATABASE_DSN" json:"database_dsn" secret:"dsn"`
 	// EnableHTTPS — флаг включения HTTPS
 	EnableHTTPS bool `env:"ENABLE_HTTPS" json:"enable_https"`
 	// ConfigPath — путь к файлу конфигурации
 	ConfigPath string `env:"CONFIG" json:"config_path"`
+	// DedupScope — область дедупликации ссылок: global, user или none
+	DedupScope string `env:"DEDUP_SCOPE" json:"dedup_scope"`
+	// CookieKeys — ключи подписи cookie в формате "id1:secret1,id2:secret2"
+	CookieKeys string `env:"COOKIE_KEYS" json:"cookie_keys" secret:"true"`
+	// CookieKeysFile — файл с ключами подписи cookie, по одному "id:secret" на строку
+	CookieKeysFile string `env:"COOKIE_KEYS_FILE" json:"cookie_keys_file"`
+	// CookieActiveKey — идентификатор ключа для подписи новых cookie (по умолчанию первый)
+	CookieActiveKey string `env:"COOKIE_ACTIVE_KEY" json:"cookie_active_key"`
+	// CookieSecure — выставлять атрибут Secure (всегда включён при EnableHTTPS)
+	CookieSecure bool `env:"COOKIE_SECURE" json:"cookie_secure"`
+	// CookieSameSite — значение атрибута SameSite: lax, strict или none
+	CookieSameSite string `env:"COOKIE_SAMESITE" json:"cookie_samesite"`
+	// CookieMaxAge — время жизни cookie; ноль означает сессионную cookie
+	CookieMaxAge Duration `env:"COOKIE_MAX_AGE" json:"cookie_max_age"`
+	// JWTSecret — секрет проверки bearer-токенов HS256
+	JWTSecret string `env:"JWT_SECRET" json:"jwt_secret" secret:"true"`
+	// JWTPublicKeyFile — PEM-файл открытого ключа проверки bearer-токенов RS256
+	JWTPublicKeyFile string `env:"JWT_PUBLIC_KEY_FILE" json:"jwt_public_key_file"`
+	// JWTIssuer — ожидаемый издатель (claim iss) bearer-токенов
+	JWTIssuer string `env:"JWT_ISSUER" json:"jwt_issuer"`
+	// JWTAudience — ожидаемая аудитория (claim aud) bearer-токенов
+	JWTAudience string `env:"JWT_AUDIENCE" json:"jwt_audience"`
+	// SessionTTL — время жизни сессии зарегистрированного пользователя
+	SessionTTL Duration `env:"SESSION_TTL" json:"session_ttl"`
+	// OIDCIssuer — URL издателя OpenID Connect; пустое значение отключает вход через SSO
+	OIDCIssuer string `env:"OIDC_ISSUER" json:"oidc_issuer"`
+	// OIDCClientID — идентификатор клиента у провайдера OpenID Connect
+	OIDCClientID string `env:"OIDC_CLIENT_ID" json:"oidc_client_id"`
+	// OIDCClientSecret — секрет клиента (необязателен для публичных клиентов)
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET" json:"oidc_client_secret" secret:"true"`
+	// OIDCRedirectURL — адрес обратного вызова, например https://short.example/api/auth/oidc/callback
+	OIDCRedirectURL string `env:"OIDC_REDIRECT_URL" json:"oidc_redirect_url"`
+	// OIDCScopes — запрашиваемые scope через запятую
+	OIDCScopes string `env:"OIDC_SCOPES" json:"oidc_scopes"`
+	// AdminUserIDs — идентификаторы пользователей-администраторов через запятую
+	AdminUserIDs string `env:"ADMIN_USER_IDS" json:"admin_user_ids"`
+	// AdminScope — scope токена, дающий права администратора; пустое значение отключает
+	AdminScope string `env:"ADMIN_SCOPE" json:"admin_scope"`
+	// TraceExporter — экспортёр спанов OpenTelemetry: none, stdout или otlp
+	TraceExporter string `env:"TRACE_EXPORTER" json:"trace_exporter"`
+	// TraceOTLPEndpoint — адрес коллектора OTLP/HTTP (host:port)
+	TraceOTLPEndpoint string `env:"TRACE_OTLP_ENDPOINT" json:"trace_otlp_endpoint"`
+	// TraceOTLPInsecure — отправлять спаны в коллектор без TLS
+	TraceOTLPInsecure bool `env:"TRACE_OTLP_INSECURE" json:"trace_otlp_insecure"`
+	// TraceSampleRatio — доля сэмплируемых корневых трасс от 0 до 1
+	TraceSampleRatio float64 `env:"TRACE_SAMPLE_RATIO" json:"trace_sample_ratio"`
+	// RateLimitShorten — лимит сокращения одной ссылки вида N/s, N/m или N/h; пустое значение отключает
+	RateLimitShorten string `env:"RATE_LIMIT_SHORTEN" json:"rate_limit_shorten"`
+	// RateLimitBatch — лимит пакетного сокращения
+	RateLimitBatch string `env:"RATE_LIMIT_BATCH" json:"rate_limit_batch"`
+	// RateLimitRedirect — лимит переходов по коротким ссылкам
+	RateLimitRedirect string `env:"RATE_LIMIT_REDIRECT" json:"rate_limit_redirect"`
+	// RateLimitAPI — лимит остальных запросов к API
+	RateLimitAPI string `env:"RATE_LIMIT_API" json:"rate_limit_api"`
+	// RateLimitAuth — лимит регистрации и входа
+	RateLimitAuth string `env:"RATE_LIMIT_AUTH" json:"rate_limit_auth"`
+	// TrustedProxies — адреса и подсети доверенных прокси через запятую
+	TrustedProxies string `env:"TRUSTED_PROXIES" json:"trusted_proxies"`
+	// LogLevel — минимальный уровень записей лога: debug, info, warn или error
+	LogLevel string `env:"LOG_LEVEL" json:"log_level"`
+	// LogFormat — формат записей лога: json или console
+	LogFormat string `env:"LOG_FORMAT" json:"log_format"`
+	// LogSampling — прореживать повторяющиеся записи лога под нагрузкой
+	LogSampling bool `env:"LOG_SAMPLING" json:"log_sampling"`
+	// LogFile — файл лога; пустое значение означает stderr
+	LogFile string `env:"LOG_FILE" json:"log_file"`
+	// LogMaxSizeMB — размер файла лога в мегабайтах, после которого он ротируется
+	LogMaxSizeMB int `env:"LOG_MAX_SIZE_MB" json:"log_max_size_mb"`
+	// LogMaxBackups — сколько ротированных файлов лога хранить
+	LogMaxBackups int `env:"LOG_MAX_BACKUPS" json:"log_max_backups"`
+	// ReadTimeout — время на чтение запроса целиком, включая тело
+	ReadTimeout Duration `env:"READ_TIMEOUT" json:"read_timeout"`
+	// ReadHeaderTimeout — время на чтение заголовков запроса
+	ReadHeaderTimeout Duration `env:"READ_HEADER_TIMEOUT" json:"read_header_timeout"`
+	// WriteTimeout — время на запись ответа
+	WriteTimeout Duration `env:"WRITE_TIMEOUT" json:"write_timeout"`
+	// IdleTimeout — время ожидания следующего запроса в keep-alive соединении
+	IdleTimeout Duration `env:"IDLE_TIMEOUT" json:"idle_timeout"`
+	// HandlerTimeout — время работы обработчика запроса
+	HandlerTimeout Duration `env:"HANDLER_TIMEOUT" json:"handler_timeout"`
+	// BatchHandlerTimeout — время работы обработчика пакетного сокращения
+	BatchHandlerTimeout Duration `env:"BATCH_HANDLER_TIMEOUT" json:"batch_handler_timeout"`
+	// MaxBodyBytes — размер тела запроса на сокращение ссылки
+	MaxBodyBytes int64 `env:"MAX_BODY_BYTES" json:"max_body_bytes"`
+	// MaxBatchBodyBytes — размер тела запроса на пакетное сокращение
+	MaxBatchBodyBytes int64 `env:"MAX_BATCH_BODY_BYTES" json:"max_batch_body_bytes"`
+	// CompressMinSize — размер ответа в байтах, начиная с которого он сжимается
+	CompressMinSize int `env:"COMPRESS_MIN_SIZE" json:"compress_min_size"`
+	// CORSAllowedOrigins — источники, которым разрешены межсайтовые запросы, через запятую; пустое значение отключает CORS
+	CORSAllowedOrigins string `env:"CORS_ALLOWED_ORIGINS" json:"cors_allowed_origins"`
+	// CORSAllowedMethods — методы, разрешённые в межсайтовых запросах, через запятую
+	CORSAllowedMethods string `env:"CORS_ALLOWED_METHODS" json:"cors_allowed_methods"`
+	// CORSAllowedHeaders — заголовки, разрешённые в межсайтовых запросах, через запятую
+	CORSAllowedHeaders string `env:"CORS_ALLOWED_HEADERS" json:"cors_allowed_headers"`
+	// CORSAllowCredentials — разрешить межсайтовым запросам передавать cookie
+	CORSAllowCredentials bool `env:"CORS_ALLOW_CREDENTIALS" json:"cors_allow_credentials"`
+	// CORSMaxAge — время кэширования ответа на preflight-запрос
+	CORSMaxAge Duration `env:"CORS_MAX_AGE" json:"cors_max_age"`
+	// CSRFProtection — проверять CSRF-токен в изменяющих запросах с cookie; по умолчанию
+	// выключено, чтобы не ломать существующих API-клиентов, не передающих токен
+	CSRFProtection bool `env:"CSRF_PROTECTION" json:"csrf_protection"`
+	// HSTSMaxAge — время действия Strict-Transport-Security при включённом HTTPS
+	HSTSMaxAge Duration `env:"HSTS_MAX_AGE" json:"hsts_max_age"`
+	// TLSCertFile — PEM-файл сертификата сервера; если не задан, при EnableHTTPS сертификат выпускается через ACME
+	TLSCertFile string `env:"TLS_CERT_FILE" json:"tls_cert_file"`
+	// TLSKeyFile — PEM-файл закрытого ключа сервера
+	TLSKeyFile string `env:"TLS_KEY_FILE" json:"tls_key_file"`
+	// TLSClientCAFile — PEM-файл УЦ для проверки клиентских сертификатов (mTLS)
+	TLSClientCAFile string `env:"TLS_CLIENT_CA_FILE" json:"tls_client_ca_file"`
+	// TLSClientAuth — проверка клиентских сертификатов: none, request, verify-if-given или require
+	TLSClientAuth string `env:"TLS_CLIENT_AUTH" json:"tls_client_auth"`
+	// TLSMinVersion — минимальная версия TLS: 1.2 или 1.3
+	TLSMinVersion string `env:"TLS_MIN_VERSION" json:"tls_min_version"`
+	// TLSMaxVersion — максимальная версия TLS; пустое значение означает последнюю
+	TLSMaxVersion string `env:"TLS_MAX_VERSION" json:"tls_max_version"`
+	// TLSCipherSuites — наборы шифров TLS 1.2 через запятую; пустое значение означает наборы по умолчанию
+	TLSCipherSuites string `env:"TLS_CIPHER_SUITES" json:"tls_cipher_suites"`
+	// TLSReloadInterval — период проверки изменений файлов сертификата
+	TLSReloadInterval Duration `env:"TLS_RELOAD_INTERVAL" json:"tls_reload_interval"`
+	// HTTPRedirectAddr — адрес HTTP-сервера, перенаправляющего на HTTPS; пустое значение отключает
+	HTTPRedirectAddr string `env:"HTTP_REDIRECT_ADDRESS" json:"http_redirect_address"`
+	// HealthCheckTimeout — время на проверку одного компонента в /readyz и /health
+	HealthCheckTimeout Duration `env:"HEALTH_CHECK_TIMEOUT" json:"health_check_timeout"`
+	// HealthMinFreeBytes — свободное место на диске файлового хранилища, ниже которого сервис не готов
+	HealthMinFreeBytes int64 `env:"HEALTH_MIN_FREE_BYTES" json:"health_min_free_bytes"`
+	// ShutdownDelay — пауза между переводом /readyz в состояние down и закрытием слушателей,
+	// чтобы балансировщик успел исключить экземпляр
+	ShutdownDelay Duration `env:"SHUTDOWN_DELAY" json:"shutdown_delay"`
+	// ShutdownTimeout — время на завершение обрабатываемых запросов при остановке
+	ShutdownTimeout Duration `env:"SHUTDOWN_TIMEOUT" json:"shutdown_timeout"`
+	// ConfigWatchInterval — период проверки изменений файла конфигурации; 0 отключает
+	ConfigWatchInterval Duration `env:"CONFIG_WATCH_INTERVAL" json:"config_watch_interval"`
+	// EnablePprof — включает /debug/pprof на служебном слушателе для администраторов; требует AdminAddr
+	EnablePprof bool `env:"ENABLE_PPROF" json:"enable_pprof"`
+	// PprofBlockRate — частота выборки блокировок горутин в наносекундах (runtime.SetBlockProfileRate); 0 отключает
+	PprofBlockRate int `env:"PPROF_BLOCK_RATE" json:"pprof_block_rate"`
+	// PprofMutexFraction — доля событий конкуренции за мьютексы в профиле (runtime.SetMutexProfileFraction); 0 отключает
+	PprofMutexFraction int `env:"PPROF_MUTEX_FRACTION" json:"pprof_mutex_fraction"`
+	// ProfileDir — каталог для периодически снимаемых CPU- и heap-профилей; пустое значение отключает
+	ProfileDir string `env:"PROFILE_DIR" json:"profile_dir"`
+	// ProfileInterval — период снятия профилей
+	ProfileInterval Duration `env:"PROFILE_INTERVAL" json:"profile_interval"`
+	// ProfileCPUDuration — длительность снятия CPU-профиля
+	ProfileCPUDuration Duration `env:"PROFILE_CPU_DURATION" json:"profile_cpu_duration"`
+	// ProfileKeep — сколько последних профилей каждого вида хранить
+	ProfileKeep int `env:"PROFILE_KEEP" json:"profile_keep"`
 }
 
-// NewConfigs создаёт структуру конфигурации, парсит флаги, переменные окружения и JSON-файл.
-func NewConfigs() *Config {
-	var c Config
-	c.parseFlags()
+// Load собирает конфигурацию из источников в порядке возрастания приоритета:
+// значения по умолчанию, файл конфигурации, переменные окружения и флаги
+// командной строки args. Путь к файлу задаётся флагом -c (-config) или
+// переменной окружения CONFIG. Ссылки на секреты разрешаются после того, как
+// собраны все источники. Load не проверяет значения настроек — для этого
+// служит Validate.
+func Load(args []string) (*Config, error) {
+	// флаги разбираются дважды: сначала отдельно, чтобы узнать путь к файлу
+	// и набор явно заданных флагов, затем поверх файла и окружения
+	var fromFlags Config
+	fs := flag.NewFlagSet("shortener", flag.ContinueOnError)
+	fromFlags.registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	c := &Config{args: args}
+	defaults := flag.NewFlagSet("shortener", flag.ContinueOnError)
+	c.registerFlags(defaults)
 
+	c.ConfigPath = fromFlags.ConfigPath
 	if c.ConfigPath == "" {
 		c.ConfigPath = os.Getenv("CONFIG")
 	}
 	if c.ConfigPath != "" {
-		_ = c.loadFromJSON(c.ConfigPath)
+		if err := c.loadFile(c.ConfigPath); err != nil {
+			return nil, fmt.Errorf("read config file %s: %w", c.ConfigPath, err)
+		}
+	}
+	if err := env.ParseWithFuncs(c, envParsers); err != nil {
+		return nil, fmt.Errorf("parse environment: %w", err)
 	}
-	env.Parse(&c)
-	return &c
-}
-
-// parseFlags настраивает и регистрирует флаги командной строки.
-func (c *Config) parseFlags() {
-	flag.StringVar(&c.ServerAddr, "a", ":8080", "Server address")
-	flag.StringVar(&c.BaseURL, "b", "http://localhost:8080", "Base URL")
-	flag.StringVar(&c.File, "f", "urls.txt", "File")
-	flag.StringVar(&c.ConnectionString, "d", "", "Connection string")
-	flag.BoolVar(&c.EnableHTTPS, "s", false, "Enable HTTPS mode")
-	flag.StringVar(&c.ConfigPath, "c", "", "Путь к JSON-файлу конфигурации")
-	flag.StringVar(&c.ConfigPath, "config", "", "Путь к JSON-файлу конфигурации (long)")
-}
 
-// loadFromJSON загружает конфиг из JSON-файла (с поддержкой комментариев).
-func (c *Config) loadFromJSON(path string) error {
-	data, err := os.ReadFile(path)
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil {
+			err = defaults.Set(f.Name, f.Value.String())
+		}
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("apply command line flags: %w", err)
+	}
+	if err := c.resolveSecrets(); err != nil {
+		return nil, err
 	}
-	return json.Unmarshal(data, c)
+	return c, nil
+}
+
+// registerFlags регистрирует флаги командной строки в fs; их значения по
+// умолчанию сразу записываются в поля c.
+func (c *Config) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.ServerAddr, "a", ":8080", "Server address")
+	fs.StringVar(&c.Listen, "listen", "", "Дополнительные адреса сервера через запятую: host:port, unix:///путь или systemd:[имя]")
+	fs.StringVar(&c.AdminAddr, "admin-addr", "", "Адрес служебного слушателя для /ping, /metrics и /internal/stats")
+	fs.StringVar(&c.UnixSocketMode, "unix-socket-mode", "0660", "Права на файлы Unix-сокетов")
+	fs.StringVar(&c.UnixSocketGroup, "unix-socket-group", "", "Группа файлов Unix-сокетов")
+	fs.StringVar(&c.BaseURL, "b", "http://localhost:8080", "Base URL")
+	fs.StringVar(&c.File, "f", "urls.txt", "File")
+	fs.StringVar(&c.ConnectionString, "d", "", "Connection string")
+	fs.BoolVar(&c.EnableHTTPS, "s", false, "Enable HTTPS mode")
+	fs.StringVar(&c.ConfigPath, "c", "", "Путь к файлу конфигурации (JSON, YAML или TOML)")
+	fs.StringVar(&c.ConfigPath, "config", "", "Путь к файлу конфигурации (long)")
+	fs.StringVar(&c.DedupScope, "dedup", "global", "Область дедупликации ссылок: global, user или none")
+	fs.StringVar(&c.CookieKeys, "cookie-keys", "", "Ключи подписи cookie в формате id:secret через запятую")
+	fs.StringVar(&c.CookieKeysFile, "cookie-keys-file", "", "Файл с ключами подписи cookie")
+	fs.StringVar(&c.CookieActiveKey, "cookie-active-key", "", "Идентификатор активного ключа подписи cookie")
+	fs.BoolVar(&c.CookieSecure, "cookie-secure", false, "Выставлять атрибут Secure у cookie")
+	fs.StringVar(&c.CookieSameSite, "cookie-samesite", "lax", "Атрибут SameSite у cookie: lax, strict или none")
+	fs.DurationVar((*time.Duration)(&c.CookieMaxAge), "cookie-max-age", 30*24*time.Hour, "Время жизни cookie")
+	fs.StringVar(&c.JWTSecret, "jwt-secret", "", "Секрет проверки bearer-токенов HS256")
+	fs.StringVar(&c.JWTPublicKeyFile, "jwt-public-key", "", "PEM-файл открытого ключа для bearer-токенов RS256")
+	fs.StringVar(&c.JWTIssuer, "jwt-issuer", "", "Ожидаемый издатель bearer-токенов")
+	fs.StringVar(&c.JWTAudience, "jwt-audience", "", "Ожидаемая аудитория bearer-токенов")
+	fs.DurationVar((*time.Duration)(&c.SessionTTL), "session-ttl", 30*24*time.Hour, "Время жизни сессии пользователя")
+	fs.StringVar(&c.OIDCIssuer, "oidc-issuer", "", "URL издателя OpenID Connect")
+	fs.StringVar(&c.OIDCClientID, "oidc-client-id", "", "Идентификатор клиента OpenID Connect")
+	fs.StringVar(&c.OIDCClientSecret, "oidc-client-secret", "", "Секрет клиента OpenID Connect")
+	fs.StringVar(&c.OIDCRedirectURL, "oidc-redirect-url", "", "Адрес обратного вызова OpenID Connect")
+	fs.StringVar(&c.OIDCScopes, "oidc-scopes", "openid,email", "Запрашиваемые scope OpenID Connect через запятую")
+	fs.StringVar(&c.AdminUserIDs, "admin-user-ids", "", "Идентификаторы пользователей-администраторов через запятую")
+	fs.StringVar(&c.AdminScope, "admin-scope", "admin", "Scope токена, дающий права администратора")
+	fs.StringVar(&c.TraceExporter, "trace-exporter", "none", "Экспортёр спанов OpenTelemetry: none, stdout или otlp")
+	fs.StringVar(&c.TraceOTLPEndpoint, "trace-otlp-endpoint", "", "Адрес коллектора OTLP/HTTP")
+	fs.BoolVar(&c.TraceOTLPInsecure, "trace-otlp-insecure", false, "Отправлять спаны в коллектор OTLP без TLS")
+	fs.Float64Var(&c.TraceSampleRatio, "trace-sample-ratio", 1, "Доля сэмплируемых трасс от 0 до 1")
+	fs.StringVar(&c.RateLimitShorten, "rate-limit-shorten", "", "Лимит сокращения ссылок (N/s, N/m или N/h), по умолчанию без ограничений")
+	fs.StringVar(&c.RateLimitBatch, "rate-limit-batch", "", "Лимит пакетного сокращения ссылок")
+	fs.StringVar(&c.RateLimitRedirect, "rate-limit-redirect", "", "Лимит переходов по коротким ссылкам")
+	fs.StringVar(&c.RateLimitAPI, "rate-limit-api", "", "Лимит остальных запросов к API")
+	fs.StringVar(&c.RateLimitAuth, "rate-limit-auth", "", "Лимит регистрации и входа")
+	fs.StringVar(&c.TrustedProxies, "trusted-proxies", "", "Адреса и подсети доверенных прокси через запятую")
+	fs.StringVar(&c.LogLevel, "log-level", "info", "Уровень логирования: debug, info, warn или error")
+	fs.StringVar(&c.LogFormat, "log-format", "json", "Формат лога: json или console")
+	fs.BoolVar(&c.LogSampling, "log-sampling", false, "Прореживать повторяющиеся записи лога")
+	fs.StringVar(&c.LogFile, "log-file", "", "Файл лога (по умолчанию stderr)")
+	fs.IntVar(&c.LogMaxSizeMB, "log-max-size", 100, "Размер файла лога в МБ для ротации, 0 — без ротации")
+	fs.IntVar(&c.LogMaxBackups, "log-max-backups", 5, "Число хранимых ротированных файлов лога")
+	fs.DurationVar((*time.Duration)(&c.ReadTimeout), "read-timeout", 15*time.Second, "Время на чтение запроса")
+	fs.DurationVar((*time.Duration)(&c.ReadHeaderTimeout), "read-header-timeout", 5*time.Second, "Время на чтение заголовков запроса")
+	fs.DurationVar((*time.Duration)(&c.WriteTimeout), "write-timeout", 60*time.Second, "Время на запись ответа")
+	fs.DurationVar((*time.Duration)(&c.IdleTimeout), "idle-timeout", 2*time.Minute, "Время простоя keep-alive соединения")
+	fs.DurationVar((*time.Duration)(&c.HandlerTimeout), "handler-timeout", 10*time.Second, "Время работы обработчика запроса")
+	fs.DurationVar((*time.Duration)(&c.BatchHandlerTimeout), "batch-handler-timeout", 30*time.Second, "Время работы обработчика пакетного сокращения")
+	fs.Int64Var(&c.MaxBodyBytes, "max-body-bytes", 64<<10, "Размер тела запроса на сокращение ссылки в байтах")
+	fs.Int64Var(&c.MaxBatchBodyBytes, "max-batch-body-bytes", 4<<20, "Размер тела запроса на пакетное сокращение в байтах")
+	fs.IntVar(&c.CompressMinSize, "compress-min-size", 1024, "Размер ответа в байтах, начиная с которого он сжимается")
+	fs.StringVar(&c.CORSAllowedOrigins, "cors-origins", "", "Источники межсайтовых запросов через запятую, * — любой")
+	fs.StringVar(&c.CORSAllowedMethods, "cors-methods", "GET,POST,PATCH,DELETE", "Методы межсайтовых запросов через запятую")
+	fs.StringVar(&c.CORSAllowedHeaders, "cors-headers", "Content-Type,Authorization,X-API-Key,X-CSRF-Token,X-Request-ID", "Заголовки межсайтовых запросов через запятую")
+	fs.BoolVar(&c.CORSAllowCredentials, "cors-credentials", false, "Разрешить межсайтовым запросам передавать cookie")
+	fs.DurationVar((*time.Duration)(&c.CORSMaxAge), "cors-max-age", 10*time.Minute, "Время кэширования ответа на preflight-запрос")
+	fs.BoolVar(&c.CSRFProtection, "csrf", false, "Проверять CSRF-токен в изменяющих запросах с cookie")
+	fs.DurationVar((*time.Duration)(&c.HSTSMaxAge), "hsts-max-age", 365*24*time.Hour, "Время действия HSTS при включённом HTTPS")
+	fs.StringVar(&c.TLSCertFile, "tls-cert", "", "PEM-файл сертификата сервера")
+	fs.StringVar(&c.TLSKeyFile, "tls-key", "", "PEM-файл закрытого ключа сервера")
+	fs.StringVar(&c.TLSClientCAFile, "tls-client-ca", "", "PEM-файл УЦ клиентских сертификатов")
+	fs.StringVar(&c.TLSClientAuth, "tls-client-auth", "", "Проверка клиентских сертификатов: none, request, verify-if-given или require")
+	fs.StringVar(&c.TLSMinVersion, "tls-min-version", "1.2", "Минимальная версия TLS")
+	fs.StringVar(&c.TLSMaxVersion, "tls-max-version", "", "Максимальная версия TLS")
+	fs.StringVar(&c.TLSCipherSuites, "tls-cipher-suites", "", "Наборы шифров TLS 1.2 через запятую")
+	fs.DurationVar((*time.Duration)(&c.TLSReloadInterval), "tls-reload-interval", time.Minute, "Период проверки изменений файлов сертификата")
+	fs.StringVar(&c.HTTPRedirectAddr, "http-redirect", "", "Адрес HTTP-сервера, перенаправляющего на HTTPS")
+	fs.DurationVar((*time.Duration)(&c.HealthCheckTimeout), "health-check-timeout", 2*time.Second, "Время на проверку одного компонента")
+	fs.Int64Var(&c.HealthMinFreeBytes, "health-min-free-bytes", 100<<20, "Минимум свободного места на диске файлового хранилища в байтах")
+	fs.DurationVar((*time.Duration)(&c.ShutdownDelay), "shutdown-delay", 0, "Пауза перед закрытием слушателей при остановке")
+	fs.DurationVar((*time.Duration)(&c.ShutdownTimeout), "shutdown-timeout", 10*time.Second, "Время на завершение запросов при остановке")
+	fs.DurationVar((*time.Duration)(&c.ConfigWatchInterval), "config-watch", 0, "Период проверки изменений файла конфигурации, 0 — только по SIGHUP")
+	fs.BoolVar(&c.EnablePprof, "pprof", false, "Включить /debug/pprof на служебном слушателе")
+	fs.IntVar(&c.PprofBlockRate, "pprof-block-rate", 0, "Частота выборки блокировок горутин в наносекундах")
+	fs.IntVar(&c.PprofMutexFraction, "pprof-mutex-fraction", 0, "Доля событий конкуренции за мьютексы в профиле")
+	fs.StringVar(&c.ProfileDir, "profile-dir", "", "Каталог для периодически снимаемых профилей")
+	fs.DurationVar((*time.Duration)(&c.ProfileInterval), "profile-interval", 10*time.Minute, "Период снятия профилей")
+	fs.DurationVar((*time.Duration)(&c.ProfileCPUDuration), "profile-cpu-duration", 30*time.Second, "Длительность снятия CPU-профиля")
+	fs.IntVar(&c.ProfileKeep, "profile-keep", 24, "Сколько последних профилей каждого вида хранить")
 }
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"server_address": ":7070",
+		"base_url": "http://file.example",
+		"log_level": "warn",
+		"dedup_scope": "user"
+	}`), 0o600))
+
+	t.Setenv("CONFIG", path)
+	t.Setenv("BASE_URL", "http://env.example")
+	t.Setenv("LOG_LEVEL", "error")
+
+	cfg, err := Load([]string{"-b", "http://flag.example"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://flag.example", cfg.BaseURL, "flag overrides env")
+	assert.Equal(t, "error", cfg.LogLevel, "env overrides file")
+	assert.Equal(t, ":7070", cfg.ServerAddr, "file overrides default")
+	assert.Equal(t, "user", cfg.DedupScope)
+	assert.Equal(t, Duration(15*time.Second), cfg.ReadTimeout, "default")
+	assert.Equal(t, path, cfg.ConfigPath)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.json")
+	require.NoError(t, os.WriteFile(unknown, []byte(`{"base_ulr": "http://typo"}`), 0o600))
+
+	_, err := Load([]string{"-c", unknown})
+	assert.ErrorContains(t, err, "base_ulr")
+
+	_, err = Load([]string{"-c", filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+
+	_, err = Load([]string{"extra"})
+	assert.ErrorContains(t, err, "unexpected arguments")
+
+	t.Setenv("READ_TIMEOUT", "soon")
+	_, err = Load(nil)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	cfg.ServerAddr = "8080"
+	cfg.BaseURL = "localhost:8080"
+	cfg.DedupScope = "everyone"
+	cfg.CookieSameSite = "none"
+	cfg.TraceSampleRatio = 2
+	cfg.RateLimitShorten = "fast"
+	cfg.TrustedProxies = "10.0.0.0/33"
+	cfg.LogLevel = "loud"
+	cfg.HandlerTimeout = Duration(-time.Second)
+	cfg.CORSAllowedOrigins = "*"
+	cfg.CORSAllowCredentials = true
+	cfg.TLSCertFile = "server.crt"
+	cfg.TLSMinVersion = "1.1"
+	cfg.EnablePprof = true
+	cfg.ProfileDir = "profiles"
+	cfg.ProfileCPUDuration = cfg.ProfileInterval
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	for _, name := range []string{
+		"server_address", "base_url", "dedup_scope", "cookie_samesite",
+		"trace_sample_ratio", "rate_limit_shorten", "trusted_proxies",
+		"log_level", "handler_timeout", "cors_allow_credentials",
+		"tls_cert_file", "tls_min_version", "enable_pprof", "profile_cpu_duration",
+	} {
+		assert.Contains(t, err.Error(), name+":")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{
+		ConnectionString: "postgres://app:hunter2@db:5432/urls",
+		CookieKeys:       "k1:supersecretsupersecretsupersecret",
+		JWTSecret:        "jwt-secret",
+		BaseURL:          "http://localhost:8080",
+	}
+	redacted := cfg.Redacted()
+
+	assert.Equal(t, "postgres://app:[REDACTED]@db:5432/urls", redacted.ConnectionString)
+	assert.Equal(t, "[REDACTED]", redacted.CookieKeys)
+	assert.Equal(t, "[REDACTED]", redacted.JWTSecret)
+	assert.Empty(t, redacted.OIDCClientSecret, "empty secrets stay empty")
+	assert.Equal(t, cfg.BaseURL, redacted.BaseURL)
+	assert.Equal(t, "jwt-secret", cfg.JWTSecret, "original is not modified")
+
+	cfg.ConnectionString = "host=db user=app password='hunter 2' dbname=urls"
+	dsn := cfg.Redacted().ConnectionString
+	assert.False(t, strings.Contains(dsn, "hunter"), dsn)
+	assert.Contains(t, dsn, "host=db user=app")
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	write("base.toml", `
+server_address = ":7000"
+log_level = "warn"
+read_timeout = "20s"
+`)
+	write("limits.yaml", `
+# лимиты вынесены в отдельный файл
+rate_limit_shorten: 5/s
+log_level: debug
+max_body_bytes: 1024
+`)
+	main := write("config.json", `{
+		// сначала base.toml, затем limits.yaml
+		"include": ["base.toml", "limits.yaml"],
+		/* значения этого файла перекрывают включённые */
+		"server_address": ":9000",
+		"base_url": "http://example.com/a//b"
+	}`)
+
+	cfg, err := Load([]string{"-c", main})
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.ServerAddr)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, Duration(20*time.Second), cfg.ReadTimeout)
+	assert.Equal(t, "5/s", cfg.RateLimitShorten)
+	assert.Equal(t, int64(1024), cfg.MaxBodyBytes)
+	assert.Equal(t, "http://example.com/a//b", cfg.BaseURL, "comment markers inside strings are kept")
+	assert.Equal(t, []string{main, filepath.Join(dir, "base.toml"), filepath.Join(dir, "limits.yaml")}, cfg.Files())
+	require.NoError(t, cfg.Validate())
+
+	yml := write("only.yml", "base_url: http://yaml.example\n")
+	cfg, err = Load([]string{"-c", yml})
+	require.NoError(t, err)
+	assert.Equal(t, "http://yaml.example", cfg.BaseURL)
+
+	write("a.yaml", "include: b.yaml\n")
+	write("b.yaml", "include: a.yaml\n")
+	_, err = Load([]string{"-c", filepath.Join(dir, "a.yaml")})
+	assert.ErrorContains(t, err, "include cycle")
+
+	bad := write("bad.toml", `log_levle = "debug"`)
+	_, err = Load([]string{"-c", bad})
+	assert.ErrorContains(t, err, "log_levle")
+}
+
+func TestLoadSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	dsnFile := filepath.Join(dir, "dsn")
+	require.NoError(t, os.WriteFile(dsnFile, []byte("postgres://app:pw@db/urls\n"), 0o600))
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("database_dsn: file:"+dsnFile+"\ncookie_keys: env:TEST_COOKIE_KEYS\n"), 0o600))
+	t.Setenv("TEST_COOKIE_KEYS", "k1:0123456789abcdef0123456789abcdef")
+	t.Setenv("JWT_SECRET", "env:TEST_JWT_SECRET")
+	t.Setenv("TEST_JWT_SECRET", "jwt-secret")
+
+	cfg, err := Load([]string{"-c", path})
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:pw@db/urls", cfg.ConnectionString)
+	assert.Equal(t, "k1:0123456789abcdef0123456789abcdef", cfg.CookieKeys)
+	assert.Equal(t, "jwt-secret", cfg.JWTSecret)
+	assert.Contains(t, cfg.Files(), dsnFile)
+	require.NoError(t, cfg.Validate())
+
+	_, err = Load([]string{"-c", path, "-d", "file:" + filepath.Join(dir, "missing")})
+	assert.ErrorContains(t, err, "database_dsn")
+
+	t.Setenv("JWT_SECRET", "env:TEST_UNSET_SECRET")
+	_, err = Load([]string{"-c", path})
+	assert.ErrorContains(t, err, "TEST_UNSET_SECRET")
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := Load(nil)
+	require.NoError(t, err)
+	b, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, a.Fingerprint(), b.Fingerprint())
+	assert.Len(t, a.Fingerprint(), 16)
+
+	b.LogLevel = "debug"
+	assert.NotEqual(t, a.Fingerprint(), b.Fingerprint())
+
+	// секреты не влияют на отпечаток
+	c, err := Load([]string{"-d", "postgres://app:one@db/urls"})
+	require.NoError(t, err)
+	d, err := Load([]string{"-d", "postgres://app:two@db/urls"})
+	require.NoError(t, err)
+	assert.Equal(t, c.Fingerprint(), d.Fingerprint())
+}
+
+func TestMerge(t *testing.T) {
+	a, err := Load(nil)
+	require.NoError(t, err)
+	b, err := Load([]string{"-a", ":9090", "-b", "https://sho.rt"})
+	require.NoError(t, err)
+
+	merged := a.Merge(b, map[string]bool{"base_url": true})
+	assert.Equal(t, "https://sho.rt", merged.BaseURL)
+	assert.Equal(t, a.ServerAddr, merged.ServerAddr)
+	assert.Equal(t, []string{"server_address"}, Changed(merged, b))
+	assert.NotEqual(t, "https://sho.rt", a.BaseURL, "the receiver is not modified")
+}
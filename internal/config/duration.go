@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/caarlos0/env"
+)
+
+// Duration — длительность, которая в файле конфигурации и переменных
+// окружения задаётся строкой в формате time.ParseDuration (например, "720h").
+type Duration time.Duration
+
+// UnmarshalJSON разбирает длительность из строки вида "30s".
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON сериализует длительность в строку вида "30s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// envParsers — парсеры переменных окружения для пользовательских типов конфигурации.
+var envParsers = env.CustomParsers{
+	reflect.TypeOf(Duration(0)): func(v string) (interface{}, error) {
+		d, err := time.ParseDuration(v)
+		return Duration(d), err
+	},
+}
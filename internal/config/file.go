@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey — ключ файла конфигурации со списком включаемых файлов.
+const includeKey = "include"
+
+// Префиксы ссылок на секреты в значениях полей с тегом secret.
+const (
+	secretFilePrefix = "file:"
+	secretEnvPrefix  = "env:"
+)
+
+// loadFile читает файл конфигурации path вместе с включёнными в него файлами
+// и записывает значения в c. Формат определяется по расширению: .yaml и .yml —
+// YAML, .toml — TOML, остальные — JSON, в котором допускаются комментарии
+// // и /* */. Ключ include задаёт файл или список файлов, которые читаются
+// раньше включающего, так что его значения имеют приоритет. Относительные
+// пути в include отсчитываются от каталога включающего файла.
+func (c *Config) loadFile(path string) error {
+	values, err := c.readFile(path, nil)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readFile разбирает файл path и рекурсивно включённые в него файлы.
+// stack — цепочка включающих файлов для обнаружения циклов.
+func (c *Config) readFile(path string, stack []string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+	}
+	stack = append(stack, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.files = append(c.files, path)
+	values, err := decodeFile(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	includes, err := includeList(values[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(values, includeKey)
+
+	merged := make(map[string]any)
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		included, err := c.readFile(include, stack)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(merged, included)
+	}
+	maps.Copy(merged, values)
+	return merged, nil
+}
+
+// decodeFile разбирает содержимое файла конфигурации в формате,
+// определённом по расширению path.
+func decodeFile(path string, data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(stripJSONComments(data)))
+		dec.UseNumber()
+		if err := dec.Decode(&values); err != nil {
+			return nil, err
+		}
+	}
+	if values == nil {
+		// пустой YAML-документ
+		values = make(map[string]any)
+	}
+	return values, nil
+}
+
+// includeList приводит значение ключа include к списку путей.
+func includeList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		paths := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("include: expected a path or a list of paths")
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, errors.New("include: expected a path or a list of paths")
+	}
+}
+
+// stripJSONComments заменяет пробелами комментарии // и /* */ вне строк,
+// сохраняя позиции остального текста для сообщений об ошибках.
+func stripJSONComments(data []byte) []byte {
+	out := bytes.Clone(data)
+	inString := false
+	for i := 0; i < len(out); i++ {
+		switch {
+		case inString:
+			if out[i] == '\\' {
+				i++
+			} else if out[i] == '"' {
+				inString = false
+			}
+		case out[i] == '"':
+			inString = true
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		}
+	}
+	return out
+}
+
+// resolveSecrets заменяет ссылки в значениях полей с тегом secret: "file:путь"
+// — содержимым файла без завершающего перевода строки, "env:ИМЯ" — значением
+// переменной окружения. Так секреты можно хранить отдельно от конфигурации.
+func (c *Config) resolveSecrets() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("secret") == "" || v.Field(i).Kind() != reflect.String {
+			continue
+		}
+		field := v.Field(i)
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		value := field.String()
+
+		switch {
+		case strings.HasPrefix(value, secretFilePrefix):
+			path := strings.TrimPrefix(value, secretFilePrefix)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: read secret: %w", name, err)
+			}
+			c.files = append(c.files, path)
+			field.SetString(strings.TrimRight(string(data), "\r\n"))
+		case strings.HasPrefix(value, secretEnvPrefix):
+			key := strings.TrimPrefix(value, secretEnvPrefix)
+			secret, ok := os.LookupEnv(key)
+			if !ok {
+				return fmt.Errorf("%s: environment variable %s is not set", name, key)
+			}
+			field.SetString(secret)
+		}
+	}
+	return nil
+}
+
+// Files возвращает файлы, из которых собрана конфигурация: основной файл,
+// включённые в него и файлы секретов. Их изменение — повод перечитать
+// конфигурацию.
+func (c *Config) Files() []string {
+	return slices.Clone(c.files)
+}
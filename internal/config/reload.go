@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Reload заново собирает конфигурацию из тех же аргументов командной строки,
+// что и c, перечитывая файл и переменные окружения, и проверяет её.
+// Ошибки возвращаются все сразу, а c при этом не меняется.
+func (c *Config) Reload() (*Config, error) {
+	next, err := Load(c.args)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Changed возвращает имена (как в файле конфигурации) настроек, значения которых в
+// a и b различаются.
+func Changed(a, b *Config) []string {
+	var names []string
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		if reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+// Merge возвращает копию c, в которой настройки с именами из names (как в
+// файле конфигурации) взяты из src. Список прочитанных файлов тоже берётся
+// из src: именно их содержимое теперь определяет конфигурацию.
+func (c *Config) Merge(src *Config, names map[string]bool) *Config {
+	merged := *c
+	merged.files = slices.Clone(src.files)
+	vm, vs := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(src).Elem()
+	t := vm.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if names[name] {
+			vm.Field(i).Set(vs.Field(i))
+		}
+	}
+	return &merged
+}
+
+// Fingerprint возвращает короткий отпечаток значений настроек, по которому
+// можно сравнить конфигурацию разных экземпляров. Секреты в отпечаток не
+// входят, чтобы его публикация ничего о них не раскрывала.
+func (c *Config) Fingerprint() string {
+	data, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/listener"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tlsconfig"
+)
+
+// Validate проверяет значения настроек и возвращает все найденные ошибки,
+// объединённые через errors.Join. Каждая ошибка начинается с имени
+// настройки, как в файле конфигурации.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if c.ServerAddr != "" || c.Listen == "" {
+		check("server_address", validateAddr(c.ServerAddr))
+	}
+	for _, addr := range strings.Split(c.Listen, ",") {
+		if strings.TrimSpace(addr) != "" {
+			_, _, err := listener.Parse(addr)
+			check("listen", err)
+		}
+	}
+	if c.AdminAddr != "" {
+		_, _, err := listener.Parse(c.AdminAddr)
+		check("admin_address", err)
+	}
+	_, err := listener.ParseMode(c.UnixSocketMode)
+	check("unix_socket_mode", err)
+	check("base_url", validateURL(c.BaseURL, true))
+	if c.ConnectionString != "" {
+		if _, err := pgx.ParseConfig(c.ConnectionString); err != nil {
+			check("database_dsn", errors.New("invalid connection string"))
+		}
+	}
+	_, err = models.ParseDedupScope(c.DedupScope)
+	check("dedup_scope", err)
+
+	_, err = auth.ParseKeys(c.CookieKeys)
+	check("cookie_keys", err)
+	sameSite, err := auth.ParseSameSite(c.CookieSameSite)
+	check("cookie_samesite", err)
+	if err == nil && sameSite == http.SameSiteNoneMode && !c.CookieSecure && !c.EnableHTTPS {
+		check("cookie_samesite", errors.New(`"none" requires cookie_secure or enable_https`))
+	}
+	check("cookie_max_age", nonNegative(c.CookieMaxAge))
+	check("session_ttl", positive(c.SessionTTL))
+
+	if c.OIDCIssuer != "" {
+		check("oidc_issuer", validateURL(c.OIDCIssuer, true))
+		if c.OIDCClientID == "" {
+			check("oidc_client_id", errors.New("required when oidc_issuer is set"))
+		}
+		check("oidc_redirect_url", validateURL(c.OIDCRedirectURL, true))
+	}
+
+	switch c.TraceExporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		check("trace_exporter", fmt.Errorf("unknown exporter %q: expected none, stdout or otlp", c.TraceExporter))
+	}
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		check("trace_sample_ratio", errors.New("must be between 0 and 1"))
+	}
+
+	for name, value := range map[string]string{
+		"rate_limit_shorten":  c.RateLimitShorten,
+		"rate_limit_batch":    c.RateLimitBatch,
+		"rate_limit_redirect": c.RateLimitRedirect,
+		"rate_limit_api":      c.RateLimitAPI,
+		"rate_limit_auth":     c.RateLimitAuth,
+	} {
+		_, err := ratelimit.ParseLimit(value)
+		check(name, err)
+	}
+	_, err = ratelimit.ParseTrustedProxies(strings.Split(c.TrustedProxies, ","))
+	check("trusted_proxies", err)
+
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		check("log_level", err)
+	}
+	switch c.LogFormat {
+	case "", "json", "console":
+	default:
+		check("log_format", fmt.Errorf("unknown format %q: expected json or console", c.LogFormat))
+	}
+	if c.LogMaxSizeMB < 0 {
+		check("log_max_size_mb", errors.New("must not be negative"))
+	}
+	if c.LogMaxBackups < 0 {
+		check("log_max_backups", errors.New("must not be negative"))
+	}
+
+	check("read_timeout", nonNegative(c.ReadTimeout))
+	check("read_header_timeout", nonNegative(c.ReadHeaderTimeout))
+	check("write_timeout", nonNegative(c.WriteTimeout))
+	check("idle_timeout", nonNegative(c.IdleTimeout))
+	check("handler_timeout", nonNegative(c.HandlerTimeout))
+	check("batch_handler_timeout", nonNegative(c.BatchHandlerTimeout))
+	if c.WriteTimeout > 0 && c.BatchHandlerTimeout > c.WriteTimeout {
+		check("batch_handler_timeout", errors.New("must not exceed write_timeout"))
+	}
+	if c.MaxBodyBytes < 0 {
+		check("max_body_bytes", errors.New("must not be negative"))
+	}
+	if c.MaxBatchBodyBytes < 0 {
+		check("max_batch_body_bytes", errors.New("must not be negative"))
+	}
+	if c.CompressMinSize < 0 {
+		check("compress_min_size", errors.New("must not be negative"))
+	}
+
+	origins := strings.Split(c.CORSAllowedOrigins, ",")
+	if c.CORSAllowCredentials && slices.ContainsFunc(origins, func(o string) bool { return strings.TrimSpace(o) == "*" }) {
+		check("cors_allow_credentials", errors.New(`cannot be combined with cors_allowed_origins "*"`))
+	}
+	check("cors_max_age", nonNegative(c.CORSMaxAge))
+	check("hsts_max_age", nonNegative(c.HSTSMaxAge))
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		check("tls_cert_file", errors.New("tls_cert_file and tls_key_file must be set together"))
+	}
+	if !c.EnableHTTPS {
+		for name, value := range map[string]string{
+			"tls_cert_file":         c.TLSCertFile,
+			"tls_client_ca_file":    c.TLSClientCAFile,
+			"http_redirect_address": c.HTTPRedirectAddr,
+		} {
+			if value != "" {
+				check(name, errors.New("requires enable_https"))
+			}
+		}
+	}
+	_, err = tlsconfig.ParseClientAuth(c.TLSClientAuth, c.TLSClientCAFile != "")
+	check("tls_client_auth", err)
+	minVersion, err := tlsconfig.ParseVersion(c.TLSMinVersion)
+	check("tls_min_version", err)
+	maxVersion, err := tlsconfig.ParseVersion(c.TLSMaxVersion)
+	check("tls_max_version", err)
+	if maxVersion != 0 && maxVersion < minVersion {
+		check("tls_max_version", errors.New("must not be lower than tls_min_version"))
+	}
+	_, err = tlsconfig.ParseCipherSuites(strings.Split(c.TLSCipherSuites, ","))
+	check("tls_cipher_suites", err)
+	check("tls_reload_interval", positive(c.TLSReloadInterval))
+	if c.HTTPRedirectAddr != "" {
+		check("http_redirect_address", validateAddr(c.HTTPRedirectAddr))
+	}
+	check("health_check_timeout", positive(c.HealthCheckTimeout))
+	if c.HealthMinFreeBytes < 0 {
+		check("health_min_free_bytes", errors.New("must not be negative"))
+	}
+	check("shutdown_delay", nonNegative(c.ShutdownDelay))
+	check("shutdown_timeout", positive(c.ShutdownTimeout))
+	check("config_watch_interval", nonNegative(c.ConfigWatchInterval))
+	if c.ConfigWatchInterval > 0 && c.ConfigPath == "" {
+		check("config_watch_interval", errors.New("requires a config file"))
+	}
+	if c.EnablePprof && c.AdminAddr == "" {
+		check("enable_pprof", errors.New("requires admin_address"))
+	}
+	if c.PprofBlockRate < 0 {
+		check("pprof_block_rate", errors.New("must not be negative"))
+	}
+	if c.PprofMutexFraction < 0 {
+		check("pprof_mutex_fraction", errors.New("must not be negative"))
+	}
+	if c.ProfileDir != "" {
+		check("profile_interval", positive(c.ProfileInterval))
+		check("profile_cpu_duration", positive(c.ProfileCPUDuration))
+		if c.ProfileCPUDuration >= c.ProfileInterval {
+			check("profile_cpu_duration", errors.New("must be shorter than profile_interval"))
+		}
+		if c.ProfileKeep < 1 {
+			check("profile_keep", errors.New("must be positive"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAddr проверяет адрес вида host:port.
+func validateAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// validateURL проверяет абсолютный URL со схемой http или https.
+func validateURL(raw string, required bool) error {
+	if raw == "" {
+		if required {
+			return errors.New("required")
+		}
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q", raw)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: expected absolute http or https URL", raw)
+	}
+	return nil
+}
+
+func nonNegative(d Duration) error {
+	if d < 0 {
+		return errors.New("must not be negative")
+	}
+	return nil
+}
+
+func positive(d Duration) error {
+	if d <= 0 {
+		return errors.New("must be positive")
+	}
+	return nil
+}
+
+// redactedValue заменяет значения секретов в Redacted.
+const redactedValue = "[REDACTED]"
+
+// dsnPassword находит пароль в DSN вида "key=value".
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Redacted возвращает копию конфигурации, в которой значения секретов
+// (поля с тегом secret) заменены на [REDACTED]. У строки подключения к базе
+// данных скрывается только пароль.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	v := reflect.ValueOf(&redacted).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.String || field.String() == "" {
+			continue
+		}
+		switch t.Field(i).Tag.Get("secret") {
+		case "true":
+			field.SetString(redactedValue)
+		case "dsn":
+			field.SetString(redactDSN(field.String()))
+		}
+	}
+	return &redacted
+}
+
+// redactDSN скрывает пароль в строке подключения в форме URL или "key=value".
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		// url.Redacted заменяет пароль на "xxxxx"
+		return strings.Replace(u.Redacted(), ":xxxxx@", ":"+redactedValue+"@", 1)
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redactedValue)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/service"
+)
+
+// AccountManager описывает интерфейс учётных записей и сессий.
+type AccountManager interface {
+	Register(ctx context.Context, email string, password string) (models.User, error)
+	Login(ctx context.Context, email string, password string) (models.User, error)
+	CreateSession(ctx context.Context, userID string) (string, time.Time, error)
+	Logout(ctx context.Context, token string) error
+	ClaimURLs(ctx context.Context, anonymousID string, userID string) (int, error)
+}
+
+// WithAccounts включает эндпоинты регистрации, входа и выхода.
+func WithAccounts(accounts AccountManager) Option {
+	return func(h *URLHandler) {
+		h.accounts = accounts
+	}
+}
+
+// Register регистрирует учётную запись и открывает для неё сессию.
+func (h *URLHandler) Register(w http.ResponseWriter, r *http.Request) {
+	h.signIn(w, r, h.accounts.Register, http.StatusCreated)
+}
+
+// Login выполняет вход в учётную запись и открывает сессию.
+func (h *URLHandler) Login(w http.ResponseWriter, r *http.Request) {
+	h.signIn(w, r, h.accounts.Login, http.StatusOK)
+}
+
+// signIn — общая часть регистрации и входа: проверяет учётные данные через
+// authenticate, выставляет cookie сессии и при необходимости переносит
+// в учётную запись ссылки текущего анонимного пользователя.
+func (h *URLHandler) signIn(w http.ResponseWriter, r *http.Request,
+	authenticate func(ctx context.Context, email, password string) (models.User, error), status int) {
+	var req models.CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := authenticate(r.Context(), req.Email, req.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrInvalidPassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrEmailTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrInvalidLogin):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("Failed to sign in", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.AccountResponse{UserID: user.ID, Email: user.Email}
+	identity, _ := middleware.IdentityFromContext(r.Context())
+	if req.ClaimAnonymous && identity.Method == auth.MethodCookie {
+		resp.Claimed, err = h.accounts.ClaimURLs(r.Context(), identity.UserID, user.ID)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to claim anonymous URLs", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	token, expires, err := h.accounts.CreateSession(r.Context(), user.ID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create session", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, h.signer.Cookie(auth.SessionCookieName, token, time.Until(expires)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Logout завершает текущую сессию и удаляет её cookie.
+func (h *URLHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil && cookie.Value != "" {
+		if err := h.accounts.Logout(r.Context(), cookie.Value); err != nil {
+			logger.FromContext(r.Context()).Error("Failed to delete session", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, h.signer.Cookie(auth.SessionCookieName, "", -1))
+	w.WriteHeader(http.StatusNoContent)
+}
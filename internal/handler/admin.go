@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/service"
+)
+
+// AdminManager описывает операции администратора над ссылками всех пользователей.
+type AdminManager interface {
+	SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error)
+	GetURL(ctx context.Context, shortURL string) (models.URLRecord, error)
+	UpdateURL(ctx context.Context, shortURL string, update models.AdminURLUpdate) (models.URLRecord, error)
+}
+
+// WithAdmin включает эндпоинты /api/admin. Доступ к ним имеют только
+// пользователи, для которых isAdmin возвращает true.
+func WithAdmin(admin AdminManager, isAdmin func(auth.Identity) bool) Option {
+	return func(h *URLHandler) {
+		h.admin = admin
+		h.isAdmin = isAdmin
+	}
+}
+
+// adminRoutes регистрирует эндпоинты администратора.
+func (h *URLHandler) adminRoutes(r chi.Router) {
+	r.Use(middleware.RequireAdmin(h.isAdmin))
+	r.Get("/urls", h.AdminSearchURLs)
+	r.Get("/urls/{key}", h.AdminGetURL)
+	r.Patch("/urls/{key}", h.AdminUpdateURL)
+	if h.logLevel != nil {
+		r.Get("/log-level", h.logLevel.ServeHTTP)
+		r.Put("/log-level", h.AdminSetLogLevel)
+	}
+}
+
+// WithLogLevel включает эндпоинт /api/admin/log-level для просмотра и
+// изменения уровня логирования без перезапуска.
+func WithLogLevel(level zap.AtomicLevel) Option {
+	return func(h *URLHandler) {
+		h.logLevel = &level
+	}
+}
+
+// AdminSetLogLevel меняет уровень логирования. Тело запроса — JSON вида
+// {"level": "debug"}; в ответе возвращается установленный уровень.
+func (h *URLHandler) AdminSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	previous := h.logLevel.Level()
+	lw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.logLevel.ServeHTTP(lw, r)
+	if lw.status == http.StatusOK && h.logLevel.Level() != previous {
+		logger.FromContext(r.Context()).Warn("Log level changed",
+			zap.Stringer("from", previous),
+			zap.Stringer("to", h.logLevel.Level()),
+		)
+	}
+}
+
+// statusRecorder запоминает код ответа вложенного обработчика.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader запоминает код ответа и передаёт его дальше.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// AdminSearchURLs ищет ссылки всех пользователей. Параметры запроса:
+// q — подстрока короткого ключа или исходного URL, user_id — владелец,
+// deleted=true — включать удалённые ссылки, limit и offset — страница.
+func (h *URLHandler) AdminSearchURLs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.URLFilter{
+		Query:          q.Get("q"),
+		UserID:         q.Get("user_id"),
+		IncludeDeleted: q.Get("deleted") == "true",
+	}
+	var err error
+	if filter.Limit, err = queryInt(q.Get("limit")); err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if filter.Offset, err = queryInt(q.Get("offset")); err != nil {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.admin.SearchURLs(r.Context(), filter)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to search URLs", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(records) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := make([]models.AdminURLResponse, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, h.adminURLResponse(record))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AdminGetURL возвращает ссылку по короткому ключу вместе с владельцем.
+func (h *URLHandler) AdminGetURL(w http.ResponseWriter, r *http.Request) {
+	record, err := h.admin.GetURL(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.adminURLResponse(record))
+}
+
+// AdminUpdateURL отключает или включает ссылку и меняет её владельца.
+// Тело запроса: {"disabled": true, "user_id": "..."}; оба поля необязательны.
+func (h *URLHandler) AdminUpdateURL(w http.ResponseWriter, r *http.Request) {
+	var update models.AdminURLUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if update.UserID != nil && strings.TrimSpace(*update.UserID) == "" {
+		http.Error(w, "user_id must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	identity, _ := middleware.IdentityFromContext(r.Context())
+	key := chi.URLParam(r, "key")
+	record, err := h.admin.UpdateURL(r.Context(), key, update)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	logger.FromContext(r.Context()).Info("Admin updated URL",
+		zap.String("admin", identity.UserID),
+		zap.String("key", key),
+		zap.String("owner", record.UserID),
+		zap.Bool("disabled", record.Disabled),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.adminURLResponse(record))
+}
+
+func (h *URLHandler) adminURLResponse(record models.URLRecord) models.AdminURLResponse {
+	return models.AdminURLResponse{
+		Key:         record.ShortURL,
+		ShortURL:    h.shortURL(record.ShortURL),
+		OriginalURL: record.OriginalURL,
+		UserID:      record.UserID,
+		DeletedFlag: record.DeletedFlag,
+		Disabled:    record.Disabled,
+		TeamID:      record.TeamID,
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrURLNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrOwnerHasURL):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.FromContext(r.Context()).Error("Admin request failed", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// queryInt разбирает неотрицательное целое из параметра запроса; пустая строка — ноль.
+func queryInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+)
+
+// maxAPIKeyNameLen — максимальная длина названия API-ключа.
+const maxAPIKeyNameLen = 100
+
+// APIKeyManager описывает интерфейс управления персональными API-ключами.
+type APIKeyManager interface {
+	CreateAPIKey(ctx context.Context, userID string, name string) (models.APIKeyResponse, error)
+	GetAPIKeys(ctx context.Context, userID string) ([]models.APIKeyResponse, error)
+	RevokeAPIKeys(ctx context.Context, userID string, ids []string) error
+}
+
+// WithAPIKeys включает эндпоинты /api/user/keys для управления API-ключами.
+func WithAPIKeys(keys APIKeyManager) Option {
+	return func(h *URLHandler) {
+		h.apiKeys = keys
+	}
+}
+
+// CreateAPIKey выпускает новый API-ключ текущему пользователю.
+// Ключ возвращается в ответе один раз и больше не может быть получен.
+func (h *URLHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > maxAPIKeyNameLen {
+		http.Error(w, "Name is too long", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeys.CreateAPIKey(r.Context(), userID, req.Name)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create API key", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// GetAPIKeys возвращает список API-ключей текущего пользователя.
+func (h *URLHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeys.GetAPIKeys(r.Context(), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get API keys", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeAPIKeys отзывает API-ключи текущего пользователя по списку идентификаторов.
+func (h *URLHandler) RevokeAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := h.apiKeys.RevokeAPIKeys(r.Context(), userID, ids); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to revoke API keys", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -22,13 +22,13 @@ type fakeShortener struct {
 	nextKeyIndex     int
 }
 
-func (f *fakeShortener) Shorten(ctx context.Context, originalURL string, userID string) (string, bool) {
+func (f *fakeShortener) Shorten(ctx context.Context, originalURL string, userID string) (models.ShortenResult, error) {
 	if f.nextKeyIndex < len(f.keys) {
 		k := f.keys[f.nextKeyIndex]
 		f.nextKeyIndex++
-		return k, false
+		return models.ShortenResult{ShortURL: k, Owned: true}, nil
 	}
-	return "abc123", false
+	return models.ShortenResult{ShortURL: "abc123", Owned: true}, nil
 }
 
 func (f *fakeShortener) GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool) {
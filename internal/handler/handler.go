@@ -3,57 +3,270 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+	"github.com/AlexeySalamakhin/URLShortener/internal/health"
 	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/metrics"
 	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
 )
 
 // URLShortener описывает интерфейс сервиса сокращения URL.
 type URLShortener interface {
-	Shorten(ctx context.Context, originalURL string, userID string) (string, bool)
+	Shorten(ctx context.Context, originalURL string, userID string) (models.ShortenResult, error)
 	GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool)
 	StoreReady() bool
 	GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error)
 	DeleteUserURLs(ctx context.Context, userID string, ids []string) error
 }
 
+// LinkOwnerHeader — заголовок ответа на конфликтующее сокращение, показывающий,
+// принадлежит ли существующая ссылка текущему пользователю ("self") или другому ("other").
+const LinkOwnerHeader = "X-Link-Owner"
+
 // URLHandler обрабатывает HTTP-запросы для сервиса сокращения URL.
 type URLHandler struct {
 	Shortener URLShortener
-	BaseURL   string
+
+	baseURL         atomic.Pointer[string]
+	signer          *auth.CookieSigner
+	authenticators  []auth.Authenticator
+	apiKeys         APIKeyManager
+	accounts        AccountManager
+	oidc            OIDCClient
+	admin           AdminManager
+	isAdmin         func(auth.Identity) bool
+	teams           TeamManager
+	metrics         *metrics.Metrics
+	limiter         *ratelimit.Limiter
+	logLevel        *zap.AtomicLevel
+	timeout         time.Duration
+	groupTimeouts   map[string]time.Duration
+	maxBodyBytes    int64
+	maxBatchBytes   int64
+	compressMinSize int
+	cors            *middleware.CORSOptions
+	csrf            bool
+	hstsMaxAge      time.Duration
+	separateAdmin   bool
+	profiling       bool
+	health          *health.Checker
+	buildInfo       *buildinfo.Info
+	storeBackend    string
+
+	configFingerprint atomic.Pointer[string]
+}
+
+// Option настраивает экземпляр URLHandler.
+type Option func(*URLHandler)
+
+// WithCookieSigner задаёт подписчик cookie с идентификатором пользователя.
+func WithCookieSigner(signer *auth.CookieSigner) Option {
+	return func(h *URLHandler) {
+		h.signer = signer
+	}
+}
+
+// WithAuthenticators задаёт аутентификаторы, которые проверяются до cookie user_id
+// (например, по bearer-токену).
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(h *URLHandler) {
+		h.authenticators = append(h.authenticators, authenticators...)
+	}
+}
+
+// WithMetrics включает сбор метрик HTTP-запросов и переходов по ссылкам
+// и эндпоинт /metrics в формате Prometheus.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *URLHandler) {
+		h.metrics = m
+	}
+}
+
+// WithRateLimiter включает ограничение частоты запросов по группам маршрутов.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(h *URLHandler) {
+		h.limiter = limiter
+	}
+}
+
+// WithTimeouts ограничивает время работы обработчиков: timeout действует для
+// всех групп маршрутов, а groupTimeouts переопределяет его для отдельных групп
+// (ключи — ratelimit.Group*).
+func WithTimeouts(timeout time.Duration, groupTimeouts map[string]time.Duration) Option {
+	return func(h *URLHandler) {
+		h.timeout = timeout
+		h.groupTimeouts = groupTimeouts
+	}
+}
+
+// WithBodyLimits ограничивает размер тела запросов на сокращение одной ссылки
+// и пакетное сокращение.
+func WithBodyLimits(maxBodyBytes, maxBatchBytes int64) Option {
+	return func(h *URLHandler) {
+		h.maxBodyBytes = maxBodyBytes
+		h.maxBatchBytes = maxBatchBytes
+	}
+}
+
+// WithCompressMinSize задаёт размер ответа, начиная с которого он сжимается.
+func WithCompressMinSize(n int) Option {
+	return func(h *URLHandler) {
+		h.compressMinSize = n
+	}
+}
+
+// WithCORS разрешает межсайтовые запросы к сервису по политике opts.
+func WithCORS(opts middleware.CORSOptions) Option {
+	return func(h *URLHandler) {
+		h.cors = &opts
+	}
+}
+
+// WithCSRF включает защиту от CSRF для изменяющих запросов пользователей,
+// определённых по cookie.
+func WithCSRF() Option {
+	return func(h *URLHandler) {
+		h.csrf = true
+	}
+}
+
+// WithHSTS добавляет к ответам заголовок Strict-Transport-Security с
+// временем действия maxAge. Используется, когда сервис работает по HTTPS.
+func WithHSTS(maxAge time.Duration) Option {
+	return func(h *URLHandler) {
+		h.hstsMaxAge = maxAge
+	}
+}
+
+// routeGroup возвращает middleware группы маршрутов: ограничение времени
+// обработки и частоты запросов, если они включены.
+func (h *URLHandler) routeGroup(group string) func(http.Handler) http.Handler {
+	timeout := h.timeout
+	if d, ok := h.groupTimeouts[group]; ok {
+		timeout = d
+	}
+	withTimeout := middleware.Timeout(timeout)
+	if h.limiter == nil {
+		return withTimeout
+	}
+	limit := middleware.RateLimit(h.limiter, group)
+	return func(next http.Handler) http.Handler {
+		return withTimeout(limit(next))
+	}
 }
 
 // NewURLHandler создаёт новый экземпляр обработчика с заданным сервисом и базовым URL.
-func NewURLHandler(shortener URLShortener, baseURL string) *URLHandler {
-	return &URLHandler{Shortener: shortener, BaseURL: baseURL}
+// Если подписчик cookie не задан, используется случайный ключ, действующий
+// до перезапуска процесса.
+func NewURLHandler(shortener URLShortener, baseURL string, opts ...Option) *URLHandler {
+	h := &URLHandler{Shortener: shortener, compressMinSize: middleware.DefaultCompressMinSize}
+	h.SetBaseURL(baseURL)
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.signer == nil {
+		h.signer, _ = auth.NewCookieSigner([]auth.Key{auth.NewRandomKey()}, "", auth.CookieOptions{})
+	}
+	return h
+}
+
+// BaseURL возвращает базовый адрес коротких ссылок.
+func (h *URLHandler) BaseURL() string {
+	return *h.baseURL.Load()
+}
+
+// SetBaseURL меняет базовый адрес коротких ссылок. Безопасен для вызова
+// во время обработки запросов.
+func (h *URLHandler) SetBaseURL(baseURL string) {
+	h.baseURL.Store(&baseURL)
+}
+
+// shortURL возвращает полный адрес короткой ссылки с ключом key.
+func (h *URLHandler) shortURL(key string) string {
+	return h.BaseURL() + "/" + key
 }
 
 // SetupRouter настраивает маршруты HTTP и возвращает роутер chi.Mux.
 func (h *URLHandler) SetupRouter() *chi.Mux {
 	rout := chi.NewRouter()
 
+	rout.Use(middleware.RequestID)
+	rout.Use(middleware.Tracing)
 	rout.Use(middleware.RequestLogger)
-	rout.Use(middleware.GzipMiddleware)
+	if h.metrics != nil {
+		rout.Use(middleware.Metrics(h.metrics))
+	}
+	rout.Use(middleware.SecurityHeaders(h.hstsMaxAge))
+	if h.cors != nil {
+		rout.Use(middleware.CORS(*h.cors))
+	}
+	rout.Use(middleware.Compress(h.compressMinSize))
+	rout.Use(middleware.Recover)
 
 	rout.Group(func(r chi.Router) {
-		r.Use(middleware.CookieMiddleware)
-		r.Post("/", h.PostURLHandlerText)
-		r.Post("/api/shorten", h.PostURLHandlerJSON)
-		r.Post("/api/shorten/batch", h.Batch)
-		r.Get("/{shortURL}", h.GetURLHandler)
-		r.Get("/api/user/urls", h.GetUserURLs)
-		r.Delete("/api/user/urls", h.DeleteUserURLs)
+		r.Use(middleware.AuthMiddleware(h.signer, h.authenticators...))
+		if h.csrf {
+			r.Use(middleware.CSRF(h.signer))
+		}
+		shorten := r.With(h.routeGroup(ratelimit.GroupShorten), middleware.BodyLimit(h.maxBodyBytes))
+		shorten.Post("/", h.PostURLHandlerText)
+		shorten.Post("/api/shorten", h.PostURLHandlerJSON)
+		r.With(h.routeGroup(ratelimit.GroupBatch), middleware.BodyLimit(h.maxBatchBytes)).Post("/api/shorten/batch", h.Batch)
+		r.With(h.routeGroup(ratelimit.GroupRedirect)).Get("/{shortURL}", h.GetURLHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.routeGroup(ratelimit.GroupAPI))
+			r.Get("/api/user/urls", h.GetUserURLs)
+			r.Delete("/api/user/urls", h.DeleteUserURLs)
+
+			if h.apiKeys != nil {
+				r.Post("/api/user/keys", h.CreateAPIKey)
+				r.Get("/api/user/keys", h.GetAPIKeys)
+				r.Delete("/api/user/keys", h.RevokeAPIKeys)
+			}
+
+			if h.teams != nil {
+				r.Route("/api/teams", h.teamRoutes)
+			}
+
+			if h.admin != nil {
+				r.Route("/api/admin", h.adminRoutes)
+			}
+		})
+
+		if h.accounts != nil {
+			r.Group(func(r chi.Router) {
+				r.Use(h.routeGroup(ratelimit.GroupAuth))
+				r.Post("/api/user/register", h.Register)
+				r.Post("/api/user/login", h.Login)
+				r.Post("/api/user/logout", h.Logout)
+
+				if h.oidc != nil {
+					r.Get("/api/auth/oidc/login", h.OIDCLogin)
+					r.Get("/api/auth/oidc/callback", h.OIDCCallback)
+				}
+			})
+		}
 	})
 
-	rout.Get("/ping", h.Ping)
+	if h.buildInfo != nil {
+		rout.With(h.routeGroup(ratelimit.GroupAPI)).Get("/api/version", h.Version)
+	}
+	if !h.separateAdmin {
+		h.serviceRoutes(rout)
+	}
 	rout.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 	})
@@ -64,6 +277,10 @@ func (h *URLHandler) SetupRouter() *chi.Mux {
 func (h *URLHandler) PostURLHandlerText(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
 	originalURL, err := io.ReadAll(r.Body)
+	if middleware.IsBodyTooLarge(err) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		w.Write([]byte(err.Error()))
 		return
@@ -77,15 +294,30 @@ func (h *URLHandler) PostURLHandlerText(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	shortKey, conflict := h.Shortener.Shorten(r.Context(), string(originalURL), userID)
+	res, err := h.Shortener.Shorten(r.Context(), string(originalURL), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to shorten URL", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeShortenStatus(w, res)
+	w.Write([]byte(h.shortURL(res.ShortURL)))
+}
 
-	if conflict {
-		w.WriteHeader(http.StatusConflict)
-	} else {
+// writeShortenStatus выставляет код ответа на сокращение URL, а при конфликте —
+// заголовок LinkOwnerHeader с информацией о владельце существующей ссылки.
+func writeShortenStatus(w http.ResponseWriter, res models.ShortenResult) {
+	if !res.Conflict {
 		w.WriteHeader(http.StatusCreated)
+		return
 	}
-
-	w.Write(fmt.Appendf(nil, "%s/%s", h.BaseURL, shortKey))
+	owner := "other"
+	if res.Owned {
+		owner = "self"
+	}
+	w.Header().Set(LinkOwnerHeader, owner)
+	w.WriteHeader(http.StatusConflict)
 }
 
 // PostURLHandlerJSON принимает JSON с полем url и возвращает JSON с результатом сокращения.
@@ -99,7 +331,11 @@ func (h *URLHandler) PostURLHandlerJSON(w http.ResponseWriter, r *http.Request)
 	var req models.ShortenRequest
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(&req); err != nil {
-		logger.Log.Error("Failed to decode JSON request", zap.Error(err))
+		if middleware.IsBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		logger.FromContext(r.Context()).Error("Failed to decode JSON request", zap.Error(err))
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
@@ -114,22 +350,26 @@ func (h *URLHandler) PostURLHandlerJSON(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shortKey, conflict := h.Shortener.Shorten(r.Context(), req.URL, userID)
+	res, err := h.Shortener.Shorten(r.Context(), req.URL, userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to shorten URL", zap.Error(err))
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
 
-	resp := models.ShortenResponse{Result: fmt.Sprintf("%s/%s", h.BaseURL, shortKey)}
+	resp := models.ShortenResponse{Result: h.shortURL(res.ShortURL)}
+	if res.Conflict {
+		resp.Owned = &res.Owned
+	}
 	jsonResp, err := json.Marshal(resp)
 	if err != nil {
 		http.Error(w, "Internal error", http.StatusInternalServerError)
-		logger.Log.Error("Failed to encode JSON request", zap.Error(err))
+		logger.FromContext(r.Context()).Error("Failed to encode JSON request", zap.Error(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if conflict {
-		w.WriteHeader(http.StatusConflict)
-	} else {
-		w.WriteHeader(http.StatusCreated)
-	}
+	writeShortenStatus(w, res)
 	w.Write(jsonResp)
 }
 
@@ -138,13 +378,21 @@ func (h *URLHandler) GetURLHandler(w http.ResponseWriter, r *http.Request) {
 	shortURL := r.URL.Path[1:]
 	record, found := h.Shortener.GetOriginalURL(r.Context(), shortURL)
 	if !found {
+		h.metrics.ObserveRedirect(metrics.RedirectNotFound)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 	if record.DeletedFlag {
+		h.metrics.ObserveRedirect(metrics.RedirectDeleted)
 		w.WriteHeader(http.StatusGone)
 		return
 	}
+	if record.Disabled {
+		h.metrics.ObserveRedirect(metrics.RedirectDisabled)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	h.metrics.ObserveRedirect(metrics.RedirectOK)
 	http.Redirect(w, r, record.OriginalURL, http.StatusTemporaryRedirect)
 }
 
@@ -162,22 +410,32 @@ func (h *URLHandler) Batch(w http.ResponseWriter, r *http.Request) {
 	var req models.ShortURLBatchRequest
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(&req); err != nil {
-		logger.Log.Error("Failed to decode JSON request", zap.Error(err))
+		if middleware.IsBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		logger.FromContext(r.Context()).Error("Failed to decode JSON request", zap.Error(err))
 		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
 	var resp []models.URLBatchResponse
 	for _, record := range req {
-		shortURL, _ := h.Shortener.Shorten(r.Context(), record.OriginalURL, "")
+		res, err := h.Shortener.Shorten(r.Context(), record.OriginalURL, userID)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to shorten URL", zap.Error(err))
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
 		resp = append(resp, models.URLBatchResponse{
 			CorrelationID: record.CorrelationID,
-			ShortURL:      fmt.Sprintf("%s/%s", h.BaseURL, shortURL),
+			ShortURL:      h.shortURL(res.ShortURL),
 		})
 	}
 	jsonResp, err := json.Marshal(resp)
 	if err != nil {
 		http.Error(w, "Internal error", http.StatusInternalServerError)
-		logger.Log.Error("Failed to encode JSON request", zap.Error(err))
+		logger.FromContext(r.Context()).Error("Failed to encode JSON request", zap.Error(err))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -195,7 +453,7 @@ func (h *URLHandler) GetUserURLs(w http.ResponseWriter, r *http.Request) {
 
 	urls, err := h.Shortener.GetUserURLs(r.Context(), userID)
 	if err != nil {
-		logger.Log.Error("Failed to get user URLs", zap.Error(err))
+		logger.FromContext(r.Context()).Error("Failed to get user URLs", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -206,12 +464,12 @@ func (h *URLHandler) GetUserURLs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for i := range urls {
-		urls[i].ShortURL = fmt.Sprintf("%s/%s", h.BaseURL, urls[i].ShortURL)
+		urls[i].ShortURL = h.shortURL(urls[i].ShortURL)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(urls); err != nil {
-		logger.Log.Error("Failed to encode response", zap.Error(err))
+		logger.FromContext(r.Context()).Error("Failed to encode response", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -229,10 +487,13 @@ func (h *URLHandler) DeleteUserURLs(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	h.metrics.DeleteQueueAdd(1)
+	// удаление переживает запрос, но сохраняет его идентификатор и трассировку
+	ctx := context.WithoutCancel(r.Context())
 	go func() {
-		ctx := context.Background()
+		defer h.metrics.DeleteQueueAdd(-1)
 		if err := h.Shortener.DeleteUserURLs(ctx, userID, ids); err != nil {
-			logger.Log.Error("Failed to delete user URLs", zap.Error(err))
+			logger.FromContext(ctx).Error("Failed to delete user URLs", zap.Error(err))
 		}
 	}()
 	w.WriteHeader(http.StatusAccepted)
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/health"
+)
+
+// WithHealth включает эндпоинты /healthz, /readyz и /health, которые
+// используют результаты проверок checker.
+func WithHealth(checker *health.Checker) Option {
+	return func(h *URLHandler) {
+		h.health = checker
+	}
+}
+
+// healthStatus — краткий ответ liveness и readiness.
+type healthStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Liveness сообщает, что процесс жив и обслуживает запросы. Зависимости не
+// проверяются: их недоступность не повод перезапускать процесс.
+func (h *URLHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, healthStatus{Status: health.StatusUp})
+}
+
+// Readiness проверяет критичные зависимости и отвечает 503, если сервис не
+// готов принимать запросы, в том числе во время завершения работы.
+func (h *URLHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.health.Draining() {
+		writeJSON(w, r, http.StatusServiceUnavailable, healthStatus{Status: health.StatusDown, Error: health.ErrDraining.Error()})
+		return
+	}
+	report := h.health.Run(r.Context())
+	if report.Status == health.StatusDown {
+		resp := healthStatus{Status: health.StatusDown}
+		for _, c := range report.Components {
+			if c.Critical && c.Status != health.StatusUp {
+				resp.Error = c.Name + ": " + c.Error
+				break
+			}
+		}
+		writeJSON(w, r, http.StatusServiceUnavailable, resp)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, healthStatus{Status: report.Status})
+}
+
+// Health возвращает подробный отчёт о состоянии компонентов: статус,
+// длительность проверки и последнюю ошибку каждого.
+func (h *URLHandler) Health(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Run(r.Context())
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, r, status, report)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/go-chi/chi"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+)
+
+// WithSeparateAdmin убирает служебные маршруты (/ping, /metrics и проверки
+// состояния) из SetupRouter: они вместе с /internal/stats обслуживаются
+// SetupAdminRouter на отдельном слушателе, недоступном снаружи.
+func WithSeparateAdmin() Option {
+	return func(h *URLHandler) {
+		h.separateAdmin = true
+	}
+}
+
+// SetupAdminRouter возвращает маршрутизатор служебных эндпоинтов для
+// отдельного административного слушателя. Аутентификации он не требует:
+// доступ к нему ограничивается сетью. Исключение — эндпоинты профилирования
+// (WithProfiling), доступные только администраторам.
+func (h *URLHandler) SetupAdminRouter() *chi.Mux {
+	rout := chi.NewRouter()
+	rout.Use(middleware.RequestID)
+	rout.Use(middleware.RequestLogger)
+	rout.Use(middleware.Recover)
+
+	h.serviceRoutes(rout)
+	rout.Get("/internal/stats", h.InternalStats)
+	if h.profiling && h.isAdmin != nil {
+		rout.Route("/debug/pprof", h.pprofRoutes)
+	}
+	return rout
+}
+
+// serviceRoutes регистрирует проверки состояния и метрики.
+func (h *URLHandler) serviceRoutes(r chi.Router) {
+	r.Get("/ping", h.Ping)
+	if h.health != nil {
+		r.Get("/healthz", h.Liveness)
+		r.Get("/readyz", h.Readiness)
+		r.Get("/health", h.Health)
+	}
+	if h.metrics != nil {
+		r.Method(http.MethodGet, "/metrics", h.metrics.Handler())
+	}
+}
+
+// internalStats — ответ /internal/stats.
+type internalStats struct {
+	UptimeSeconds  int64  `json:"uptime_seconds"`
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	GCCycles       uint32 `json:"gc_cycles"`
+	StoreReady     bool   `json:"store_ready"`
+}
+
+// InternalStats возвращает состояние процесса: время работы, число горутин,
+// использование памяти и доступность хранилища.
+func (h *URLHandler) InternalStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	writeJSON(w, r, http.StatusOK, internalStats{
+		UptimeSeconds:  int64(buildinfo.Uptime().Seconds()),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		SysBytes:       mem.Sys,
+		GCCycles:       mem.NumGC,
+		StoreReady:     h.Shortener.StoreReady(),
+	})
+}
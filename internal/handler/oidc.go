@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+)
+
+// oidcStateCookieName — cookie, в которой между началом входа и обратным
+// вызовом хранятся state, nonce и code_verifier.
+const oidcStateCookieName = "oidc_state"
+
+// oidcStateTTL — сколько времени пользователь может провести на странице входа провайдера.
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCClient описывает клиента провайдера OpenID Connect.
+type OIDCClient interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (auth.OIDCClaims, error)
+}
+
+// WithOIDC включает вход через провайдера OpenID Connect. Для работы
+// также нужен AccountManager (см. WithAccounts), который открывает сессии.
+func WithOIDC(client OIDCClient) Option {
+	return func(h *URLHandler) {
+		h.oidc = client
+	}
+}
+
+// oidcState — содержимое cookie oidc_state.
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to,omitempty"`
+	Claim    bool   `json:"claim,omitempty"`
+}
+
+// OIDCLogin начинает вход через провайдера: сохраняет state, nonce и
+// code_verifier в подписанной cookie и перенаправляет на страницу провайдера.
+// Параметр return_to задаёт локальный путь, куда вернуть пользователя после
+// входа, а claim_anonymous=true переносит ссылки анонимного пользователя.
+func (h *URLHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	verifier, challenge := auth.GeneratePKCE()
+	st := oidcState{
+		State:    auth.GenerateSessionToken(),
+		Nonce:    auth.GenerateSessionToken(),
+		Verifier: verifier,
+		Claim:    r.URL.Query().Get("claim_anonymous") == "true",
+	}
+	if returnTo := r.URL.Query().Get("return_to"); isLocalPath(returnTo) {
+		st.ReturnTo = returnTo
+	}
+
+	target, err := h.oidc.AuthCodeURL(r.Context(), st.State, st.Nonce, challenge)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to build OIDC authorization URL", zap.Error(err))
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	data, _ := json.Marshal(st)
+	cookie := h.signer.Cookie(oidcStateCookieName,
+		h.signer.Sign(oidcStateCookieName, base64.RawURLEncoding.EncodeToString(data)), oidcStateTTL)
+	// обратный вызов — межсайтовая навигация от провайдера, cookie со Strict в неё не попадёт
+	cookie.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// OIDCCallback завершает вход: проверяет state, обменивает код на ID-токен
+// и открывает сессию, в которой идентификатором пользователя служит claim sub.
+func (h *URLHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	st, ok := h.readOIDCState(r)
+	http.SetCookie(w, h.signer.Cookie(oidcStateCookieName, "", -1))
+	if !ok || subtle.ConstantTimeCompare([]byte(st.State), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, "Invalid OIDC state", http.StatusBadRequest)
+		return
+	}
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		http.Error(w, "OIDC login failed: "+errCode, http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := h.oidc.Exchange(r.Context(), r.URL.Query().Get("code"), st.Verifier, st.Nonce)
+	if err != nil {
+		logger.FromContext(r.Context()).Info("OIDC code exchange failed", zap.Error(err))
+		http.Error(w, "OIDC login failed", http.StatusUnauthorized)
+		return
+	}
+
+	resp := models.AccountResponse{UserID: claims.Subject, Email: claims.Email}
+	identity, _ := middleware.IdentityFromContext(r.Context())
+	if st.Claim && identity.Method == auth.MethodCookie {
+		resp.Claimed, err = h.accounts.ClaimURLs(r.Context(), identity.UserID, claims.Subject)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to claim anonymous URLs", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	token, expires, err := h.accounts.CreateSession(r.Context(), claims.Subject)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create session", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, h.signer.Cookie(auth.SessionCookieName, token, time.Until(expires)))
+
+	if st.ReturnTo != "" {
+		http.Redirect(w, r, st.ReturnTo, http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *URLHandler) readOIDCState(r *http.Request) (oidcState, bool) {
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		return oidcState{}, false
+	}
+	encoded, _, ok := h.signer.Verify(oidcStateCookieName, cookie.Value)
+	if !ok {
+		return oidcState{}, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return oidcState{}, false
+	}
+	var st oidcState
+	if err := json.Unmarshal(data, &st); err != nil || st.State == "" {
+		return oidcState{}, false
+	}
+	return st, true
+}
+
+// isLocalPath сообщает, что путь ведёт на этот же сервис, а не на внешний сайт.
+func isLocalPath(p string) bool {
+	return strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "//") && !strings.Contains(p, `\`)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http/pprof"
+
+	"github.com/go-chi/chi"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+)
+
+// WithProfiling включает эндпоинты net/http/pprof и трассировки выполнения
+// под /debug/pprof на служебном слушателе. Доступ к ним имеют только
+// администраторы (см. WithAdmin), поэтому без WithAdmin они не регистрируются.
+func WithProfiling() Option {
+	return func(h *URLHandler) {
+		h.profiling = true
+	}
+}
+
+// pprofRoutes регистрирует эндпоинты профилирования. Снятие CPU-профиля
+// и трассировки длится секунды, поэтому ограничение времени обработчиков
+// к ним не применяется.
+func (h *URLHandler) pprofRoutes(r chi.Router) {
+	r.Use(middleware.AuthMiddleware(h.signer, h.authenticators...))
+	r.Use(middleware.RequireAdmin(h.isAdmin))
+	r.Get("/", pprof.Index)
+	r.Get("/cmdline", pprof.Cmdline)
+	r.Get("/profile", pprof.Profile)
+	r.Get("/symbol", pprof.Symbol)
+	r.Get("/trace", pprof.Trace)
+	// heap, goroutine, allocs, block, mutex, threadcreate
+	r.Get("/{profile}", pprof.Index)
+}
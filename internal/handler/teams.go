@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/middleware"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/service"
+)
+
+// TeamManager описывает интерфейс рабочих пространств. Права участников
+// проверяются на стороне TeamManager.
+type TeamManager interface {
+	CreateTeam(ctx context.Context, userID string, name string) (models.TeamResponse, error)
+	GetTeams(ctx context.Context, userID string) ([]models.TeamResponse, error)
+	GetTeamMembers(ctx context.Context, userID string, teamID string) ([]models.TeamMember, error)
+	CreateTeamInvite(ctx context.Context, userID string, teamID string, role models.TeamRole) (models.TeamInviteResponse, error)
+	JoinTeam(ctx context.Context, userID string, token string) (models.TeamResponse, error)
+	RemoveTeamMember(ctx context.Context, userID string, teamID string, memberID string) error
+	ShortenInTeam(ctx context.Context, userID string, teamID string, originalURL string) (models.ShortenResult, error)
+	GetTeamURLs(ctx context.Context, userID string, teamID string) ([]models.URLRecord, error)
+	EditTeamURL(ctx context.Context, userID string, teamID string, shortURL string, originalURL string) error
+	DeleteTeamURLs(ctx context.Context, userID string, teamID string, ids []string) error
+}
+
+// WithTeams включает эндпоинты /api/teams для работы с рабочими пространствами.
+func WithTeams(teams TeamManager) Option {
+	return func(h *URLHandler) {
+		h.teams = teams
+	}
+}
+
+// teamRoutes регистрирует эндпоинты рабочих пространств.
+func (h *URLHandler) teamRoutes(r chi.Router) {
+	r.Post("/", h.CreateTeam)
+	r.Get("/", h.GetTeams)
+	r.Post("/join", h.JoinTeam)
+	r.Get("/{teamID}/members", h.GetTeamMembers)
+	r.Delete("/{teamID}/members/{userID}", h.RemoveTeamMember)
+	r.Post("/{teamID}/invites", h.CreateTeamInvite)
+	r.Post("/{teamID}/urls", h.ShortenInTeam)
+	r.Get("/{teamID}/urls", h.GetTeamURLs)
+	r.Patch("/{teamID}/urls/{key}", h.EditTeamURL)
+	r.Delete("/{teamID}/urls", h.DeleteTeamURLs)
+}
+
+// CreateTeam создаёт рабочее пространство; текущий пользователь становится его администратором.
+func (h *URLHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req models.CreateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teams.CreateTeam(r.Context(), userID, req.Name)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, team)
+}
+
+// GetTeams возвращает рабочие пространства текущего пользователя.
+func (h *URLHandler) GetTeams(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	teams, err := h.teams.GetTeams(r.Context(), userID)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	if len(teams) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, teams)
+}
+
+// GetTeamMembers возвращает участников рабочего пространства.
+func (h *URLHandler) GetTeamMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	members, err := h.teams.GetTeamMembers(r.Context(), userID, chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, members)
+}
+
+// CreateTeamInvite выпускает приглашение в рабочее пространство.
+// Токен приглашения возвращается в ответе один раз.
+func (h *URLHandler) CreateTeamInvite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req models.CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	role, err := models.ParseTeamRole(req.Role)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	invite, err := h.teams.CreateTeamInvite(r.Context(), userID, chi.URLParam(r, "teamID"), role)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusCreated, invite)
+}
+
+// JoinTeam принимает приглашение в рабочее пространство.
+func (h *URLHandler) JoinTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req models.JoinTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.teams.JoinTeam(r.Context(), userID, req.Token)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, team)
+}
+
+// RemoveTeamMember исключает участника из рабочего пространства или выводит из него текущего пользователя.
+func (h *URLHandler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	err := h.teams.RemoveTeamMember(r.Context(), userID, chi.URLParam(r, "teamID"), chi.URLParam(r, "userID"))
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShortenInTeam сокращает URL в рабочее пространство. Формат запроса
+// и ответа совпадает с /api/shorten.
+func (h *URLHandler) ShortenInTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req models.ShortenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if _, err := url.ParseRequestURI(req.URL); err != nil {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.teams.ShortenInTeam(r.Context(), userID, chi.URLParam(r, "teamID"), req.URL)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	resp := models.ShortenResponse{Result: h.shortURL(res.ShortURL)}
+	if res.Conflict {
+		resp.Owned = &res.Owned
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeShortenStatus(w, res)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTeamURLs возвращает ссылки рабочего пространства с их авторами.
+func (h *URLHandler) GetTeamURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	records, err := h.teams.GetTeamURLs(r.Context(), userID, chi.URLParam(r, "teamID"))
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	if len(records) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	urls := make([]models.TeamURLResponse, 0, len(records))
+	for _, record := range records {
+		urls = append(urls, models.TeamURLResponse{
+			ShortURL:    h.shortURL(record.ShortURL),
+			OriginalURL: record.OriginalURL,
+			CreatedBy:   record.UserID,
+		})
+	}
+	writeJSON(w, r, http.StatusOK, urls)
+}
+
+// EditTeamURL меняет исходный URL ссылки рабочего пространства.
+// Тело запроса совпадает с /api/shorten.
+func (h *URLHandler) EditTeamURL(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req models.ShortenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if _, err := url.ParseRequestURI(req.URL); err != nil {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	err := h.teams.EditTeamURL(r.Context(), userID, chi.URLParam(r, "teamID"), chi.URLParam(r, "key"), req.URL)
+	if err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteTeamURLs помечает удалёнными ссылки рабочего пространства.
+// Тело запроса — JSON-массив коротких ключей.
+func (h *URLHandler) DeleteTeamURLs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.teams.DeleteTeamURLs(r.Context(), userID, chi.URLParam(r, "teamID"), ids); err != nil {
+		writeTeamError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTeamError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTeamName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrTeamNotFound), errors.Is(err, service.ErrURLNotFound),
+		errors.Is(err, service.ErrInvalidInvite):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrTeamForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrLastTeamAdmin), errors.Is(err, service.ErrURLExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.FromContext(r.Context()).Error("Team request failed", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to encode response", zap.Error(err))
+	}
+}
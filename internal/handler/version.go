@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+)
+
+// WithBuildInfo включает эндпоинт /api/version со сведениями о сборке info
+// и названием хранилища storeBackend.
+func WithBuildInfo(info buildinfo.Info, storeBackend string) Option {
+	return func(h *URLHandler) {
+		h.buildInfo = &info
+		h.storeBackend = storeBackend
+	}
+}
+
+// SetConfigFingerprint задаёт отпечаток действующей конфигурации для
+// /api/version и метрики config_info. Безопасен для вызова во время
+// обработки запросов.
+func (h *URLHandler) SetConfigFingerprint(fingerprint string) {
+	h.configFingerprint.Store(&fingerprint)
+	h.metrics.SetConfigFingerprint(fingerprint)
+}
+
+// versionResponse — ответ /api/version.
+type versionResponse struct {
+	buildinfo.Info
+	StartedAt         time.Time `json:"started_at"`
+	UptimeSeconds     int64     `json:"uptime_seconds"`
+	StoreBackend      string    `json:"store_backend"`
+	ConfigFingerprint string    `json:"config_fingerprint,omitempty"`
+}
+
+// Version возвращает сведения о сборке, время работы процесса, название
+// хранилища и отпечаток конфигурации.
+func (h *URLHandler) Version(w http.ResponseWriter, r *http.Request) {
+	resp := versionResponse{
+		Info:          *h.buildInfo,
+		StartedAt:     buildinfo.StartTime.UTC(),
+		UptimeSeconds: int64(buildinfo.Uptime().Seconds()),
+		StoreBackend:  h.storeBackend,
+	}
+	if fp := h.configFingerprint.Load(); fp != nil {
+		resp.ConfigFingerprint = *fp
+	}
+	writeJSON(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package health
+
+import "context"
+
+// DiskSpace на этой платформе не проверяет свободное место.
+func DiskSpace(dir string, minFree uint64) Check {
+	return func(ctx context.Context) error {
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// DiskSpace возвращает проверку того, что на файловой системе каталога dir
+// свободно не меньше minFree байт.
+func DiskSpace(dir string, minFree uint64) Check {
+	return func(ctx context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var stat unix.Statfs_t
+		if err := unix.Statfs(dir, &stat); err != nil {
+			return err
+		}
+		free := stat.Bavail * uint64(stat.Bsize)
+		if free < minFree {
+			return fmt.Errorf("only %d bytes free in %s, need at least %d", free, dir, minFree)
+		}
+		return nil
+	}
+}
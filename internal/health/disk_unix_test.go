@@ -0,0 +1,17 @@
+//go:build unix
+
+package health
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, DiskSpace(dir, 1)(context.Background()))
+	assert.Error(t, DiskSpace(dir, math.MaxUint64)(context.Background()))
+}
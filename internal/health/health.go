@@ -0,0 +1,177 @@
+// Package health проверяет зависимости сервиса и хранит результаты проверок
+// для эндпоинтов liveness, readiness и подробного отчёта о состоянии.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check проверяет компонент и возвращает ошибку, если он неработоспособен.
+// Проверка должна завершаться при отмене ctx.
+type Check func(ctx context.Context) error
+
+// Состояния компонентов и сервиса в целом.
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// ErrDraining — причина неготовности во время завершения работы.
+var ErrDraining = errors.New("server is shutting down")
+
+// ComponentStatus — результат последней проверки компонента.
+type ComponentStatus struct {
+	Name string `json:"name"`
+	// Status — StatusUp или StatusDown.
+	Status string `json:"status"`
+	// Critical — от компонента зависит готовность сервиса.
+	Critical bool `json:"critical"`
+	// LatencyMS — длительность проверки в миллисекундах.
+	LatencyMS float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Error — ошибка текущей проверки.
+	Error string `json:"error,omitempty"`
+	// LastError и LastErrorAt — последняя ошибка компонента, даже если
+	// с тех пор он восстановился.
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Report — состояние сервиса: StatusDown, если недоступен критичный
+// компонент или сервис завершает работу, StatusDegraded, если недоступен
+// некритичный компонент, иначе StatusUp.
+type Report struct {
+	Status     string            `json:"status"`
+	Draining   bool              `json:"draining,omitempty"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// component — зарегистрированная проверка и её последние результаты.
+type component struct {
+	name     string
+	check    Check
+	critical bool
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Checker выполняет проверки зарегистрированных компонентов.
+type Checker struct {
+	timeout    time.Duration
+	mu         sync.RWMutex
+	components []*component
+	draining   atomic.Bool
+	now        func() time.Time
+}
+
+// New создаёт Checker, ограничивающий каждую проверку временем timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, now: time.Now}
+}
+
+// Register добавляет компонент name. Недоступность критичного компонента
+// делает сервис неготовым, некритичного — только ухудшает отчёт.
+func (c *Checker) Register(name string, check Check, critical bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.components = append(c.components, &component{name: name, check: check, critical: critical})
+}
+
+// SetDraining переводит сервис в режим завершения: с этого момента он
+// сообщает о неготовности, чтобы балансировщик перестал направлять запросы.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Draining сообщает, завершает ли сервис работу.
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Run параллельно проверяет все компоненты и возвращает отчёт.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	components := c.components
+	c.mu.RUnlock()
+
+	report := Report{
+		Status:     StatusUp,
+		Draining:   c.Draining(),
+		Components: make([]ComponentStatus, len(components)),
+	}
+	var wg sync.WaitGroup
+	for i, comp := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[i] = c.run(ctx, comp)
+		}()
+	}
+	wg.Wait()
+
+	for _, status := range report.Components {
+		switch {
+		case status.Status == StatusUp:
+		case status.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	if report.Draining {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// run выполняет проверку одного компонента с ограничением по времени.
+func (c *Checker) run(ctx context.Context, comp *component) ComponentStatus {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := c.now()
+	err := runCheck(ctx, comp.check)
+	status := ComponentStatus{
+		Name:      comp.name,
+		Status:    StatusUp,
+		Critical:  comp.critical,
+		LatencyMS: float64(c.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+
+	comp.mu.Lock()
+	defer comp.mu.Unlock()
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+		comp.lastError, comp.lastErrorAt = err.Error(), start
+	}
+	if comp.lastError != "" {
+		at := comp.lastErrorAt
+		status.LastError, status.LastErrorAt = comp.lastError, &at
+	}
+	return status
+}
+
+// runCheck выполняет проверку, не дожидаясь её дольше, чем живёт ctx:
+// проверка, игнорирующая отмену, не должна задерживать ответ.
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
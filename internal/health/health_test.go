@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckerRun(t *testing.T) {
+	var storeErr error
+	c := New(50 * time.Millisecond)
+	c.Register("store", func(context.Context) error { return storeErr }, true)
+	c.Register("cache", func(context.Context) error { return errors.New("cache unavailable") }, false)
+	c.Register("slow", func(ctx context.Context) error {
+		// проверка, не реагирующая на отмену, не задерживает отчёт
+		time.Sleep(time.Second)
+		return nil
+	}, false)
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusDegraded, report.Status)
+	require.Len(t, report.Components, 3)
+	assert.Equal(t, StatusUp, report.Components[0].Status)
+	assert.Empty(t, report.Components[0].LastError)
+	assert.Equal(t, "cache unavailable", report.Components[1].Error)
+	assert.Equal(t, StatusDown, report.Components[2].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components[2].Error)
+	assert.GreaterOrEqual(t, report.Components[2].LatencyMS, float64(50))
+
+	storeErr = errors.New("connection refused")
+	report = c.Run(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Components[0].Error)
+
+	// после восстановления последняя ошибка остаётся в отчёте
+	storeErr = nil
+	report = c.Run(context.Background())
+	assert.Equal(t, StatusUp, report.Components[0].Status)
+	assert.Empty(t, report.Components[0].Error)
+	assert.Equal(t, "connection refused", report.Components[0].LastError)
+	assert.NotNil(t, report.Components[0].LastErrorAt)
+}
+
+func TestCheckerDraining(t *testing.T) {
+	c := New(time.Second)
+	c.Register("store", func(context.Context) error { return nil }, true)
+	assert.Equal(t, StatusUp, c.Run(context.Background()).Status)
+
+	c.SetDraining()
+	report := c.Run(context.Background())
+	assert.True(t, report.Draining)
+	assert.Equal(t, StatusDown, report.Status)
+}
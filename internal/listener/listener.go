@@ -0,0 +1,148 @@
+// Package listener открывает сокеты, на которых сервер принимает соединения:
+// TCP, Unix-сокеты с заданными правами и сокеты, переданные systemd
+// (socket activation).
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// Схемы адресов слушателей.
+const (
+	SchemeTCP     = "tcp"
+	SchemeUnix    = "unix"
+	SchemeSystemd = "systemd"
+)
+
+// Options — параметры создаваемых Unix-сокетов.
+type Options struct {
+	// UnixMode — права на файл сокета; ноль оставляет права по umask.
+	UnixMode os.FileMode
+	// UnixGroup — группа (имя или числовой gid) файла сокета; пустое
+	// значение оставляет группу процесса.
+	UnixGroup string
+}
+
+// Parse разбирает адрес слушателя и возвращает схему и адрес внутри неё:
+//
+//	host:port, tcp://host:port    — TCP
+//	unix:///run/app.sock, unix:…  — Unix-сокет
+//	systemd:, systemd:name        — сокеты от systemd, все или с именем
+//	                                 из FileDescriptorName
+func Parse(addr string) (scheme, address string, err error) {
+	addr = strings.TrimSpace(addr)
+	scheme, rest, found := strings.Cut(addr, ":")
+	switch {
+	case found && scheme == SchemeUnix:
+		path := strings.TrimPrefix(rest, "//")
+		if path == "" {
+			return "", "", fmt.Errorf("invalid listener %q: empty socket path", addr)
+		}
+		return SchemeUnix, path, nil
+	case found && scheme == SchemeSystemd:
+		return SchemeSystemd, strings.TrimPrefix(rest, "//"), nil
+	case found && scheme == SchemeTCP && strings.HasPrefix(rest, "//"):
+		addr = strings.TrimPrefix(rest, "//")
+	}
+	if _, port, err := net.SplitHostPort(addr); err != nil {
+		return "", "", fmt.Errorf("invalid listener %q: %w", addr, err)
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", fmt.Errorf("invalid listener %q: bad port %q", addr, port)
+	}
+	return SchemeTCP, addr, nil
+}
+
+// Listen открывает слушателей по адресу addr в формате Parse. Адрес systemd
+// без имени возвращает все переданные процессу сокеты, поэтому результат —
+// список.
+func Listen(addr string, opts Options) ([]net.Listener, error) {
+	scheme, address, err := Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	switch scheme {
+	case SchemeUnix:
+		l, err := listenUnix(address, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	case SchemeSystemd:
+		return systemdListeners(address)
+	default:
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+}
+
+// listenUnix создаёт Unix-сокет path, удалив оставшийся от прошлого запуска
+// файл сокета, и выставляет ему права и группу из opts.
+func listenUnix(path string, opts Options) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen unix %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("listen unix %s: remove stale socket: %w", path, err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := setPermissions(path, opts); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("listen unix %s: %w", path, err)
+	}
+	return l, nil
+}
+
+func setPermissions(path string, opts Options) error {
+	if opts.UnixGroup != "" {
+		gid, err := lookupGroup(opts.UnixGroup)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return err
+		}
+	}
+	if opts.UnixMode != 0 {
+		return os.Chmod(path, opts.UnixMode)
+	}
+	return nil
+}
+
+// lookupGroup возвращает gid группы по имени или числовому идентификатору.
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// ParseMode разбирает восьмеричные права файла вида "0660".
+func ParseMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, errors.New("invalid file mode: expected octal permissions such as 0660")
+	}
+	return os.FileMode(mode), nil
+}
@@ -0,0 +1,110 @@
+package listener
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		addr, scheme, address string
+		wantErr               bool
+	}{
+		{addr: ":8080", scheme: SchemeTCP, address: ":8080"},
+		{addr: "localhost:8080", scheme: SchemeTCP, address: "localhost:8080"},
+		{addr: "tcp://127.0.0.1:9000", scheme: SchemeTCP, address: "127.0.0.1:9000"},
+		{addr: "[::1]:8080", scheme: SchemeTCP, address: "[::1]:8080"},
+		{addr: "unix:///run/shortener.sock", scheme: SchemeUnix, address: "/run/shortener.sock"},
+		{addr: "unix:shortener.sock", scheme: SchemeUnix, address: "shortener.sock"},
+		{addr: "systemd:", scheme: SchemeSystemd, address: ""},
+		{addr: "systemd:admin", scheme: SchemeSystemd, address: "admin"},
+		{addr: "unix://", wantErr: true},
+		{addr: "localhost", wantErr: true},
+		{addr: ":http-alt", wantErr: true},
+		{addr: ":70000", wantErr: true},
+	}
+	for _, tt := range tests {
+		scheme, address, err := Parse(tt.addr)
+		if tt.wantErr {
+			assert.Error(t, err, tt.addr)
+			continue
+		}
+		require.NoError(t, err, tt.addr)
+		assert.Equal(t, tt.scheme, scheme, tt.addr)
+		assert.Equal(t, tt.address, address, tt.addr)
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shortener.sock")
+	listeners, err := Listen("unix://"+path, Options{UnixMode: 0o660, UnixGroup: strconv.Itoa(os.Getgid())})
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	go srv.Serve(listeners[0])
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", path) },
+	}}
+	resp, err := client.Get("http://unix/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// сокет, оставшийся после аварийного завершения, заменяется
+	stale, err := net.Listen("unix", filepath.Join(t.TempDir(), "stale.sock"))
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stalePath := stale.Addr().String()
+	stale.Close()
+	listeners, err = Listen("unix:"+stalePath, Options{})
+	require.NoError(t, err)
+	listeners[0].Close()
+
+	// обычный файл по пути сокета не удаляется
+	regular := filepath.Join(t.TempDir(), "data.txt")
+	require.NoError(t, os.WriteFile(regular, []byte("keep"), 0o600))
+	_, err = Listen("unix:"+regular, Options{})
+	assert.ErrorContains(t, err, "not a socket")
+}
+
+func TestInheritSockets(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	env := map[string]string{
+		"LISTEN_PID":     "42",
+		"LISTEN_FDS":     "1",
+		"LISTEN_FDNAMES": "public",
+	}
+	sockets, err := inheritSockets(func(k string) string { return env[k] }, 42, int(f.Fd()))
+	require.NoError(t, err)
+	require.Len(t, sockets, 1)
+	defer sockets[0].listener.Close()
+	assert.Equal(t, "public", sockets[0].name)
+	assert.Equal(t, l.Addr().String(), sockets[0].listener.Addr().String())
+
+	// переменные, адресованные другому процессу, игнорируются
+	sockets, err = inheritSockets(func(k string) string { return env[k] }, 7, int(f.Fd()))
+	require.NoError(t, err)
+	assert.Empty(t, sockets)
+}
@@ -0,0 +1,87 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFDsStart — первый дескриптор, передаваемый systemd.
+const listenFDsStart = 3
+
+// systemdSocket — сокет, полученный от systemd.
+type systemdSocket struct {
+	name     string
+	listener net.Listener
+	taken    bool
+}
+
+var (
+	systemdOnce    sync.Once
+	systemdMu      sync.Mutex
+	systemdSockets []*systemdSocket
+	systemdErr     error
+)
+
+// systemdListeners возвращает ещё не выданные сокеты, переданные процессу
+// systemd, — все или только с именем name. Каждый сокет выдаётся один раз.
+func systemdListeners(name string) ([]net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdSockets, systemdErr = inheritSockets(os.Getenv, os.Getpid(), listenFDsStart)
+	})
+	if systemdErr != nil {
+		return nil, systemdErr
+	}
+
+	systemdMu.Lock()
+	defer systemdMu.Unlock()
+	var listeners []net.Listener
+	for _, s := range systemdSockets {
+		if s.taken || (name != "" && s.name != name) {
+			continue
+		}
+		s.taken = true
+		listeners = append(listeners, s.listener)
+	}
+	if len(listeners) == 0 {
+		if name == "" {
+			return nil, fmt.Errorf("listen systemd: no sockets passed by systemd")
+		}
+		return nil, fmt.Errorf("listen systemd: no socket named %q passed by systemd", name)
+	}
+	return listeners, nil
+}
+
+// inheritSockets разбирает переменные LISTEN_PID, LISTEN_FDS и
+// LISTEN_FDNAMES протокола sd_listen_fds и открывает переданные сокеты,
+// начиная с дескриптора start.
+func inheritSockets(getenv func(string) string, pid int, start int) ([]*systemdSocket, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("listen systemd: invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+	names := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+
+	sockets := make([]*systemdSocket, 0, count)
+	for i := 0; i < count; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(start+i), name)
+		// FileListener дублирует дескриптор, исходный больше не нужен
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("listen systemd: socket %d (%s): %w", start+i, name, err)
+		}
+		sockets = append(sockets, &systemdSocket{name: name, listener: l})
+	}
+	return sockets, nil
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// RequestIDField — имя поля записи лога с идентификатором запроса.
+const RequestIDField = "request_id"
+
+// WithRequestID сохраняет идентификатор запроса в контексте.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// FromContext возвращает глобальный логгер, дополненный идентификатором
+// запроса из контекста, если он есть.
+func FromContext(ctx context.Context) *zap.Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return Log.With(zap.String(RequestIDField, requestID))
+	}
+	return Log
+}
+
+// StoreHook — хук store.ObservedStore, записывающий в лог сбои операций
+// хранилища вместе с идентификатором запроса.
+func StoreHook(ctx context.Context, op string) (context.Context, func(failure error)) {
+	return ctx, func(failure error) {
+		if failure != nil {
+			FromContext(ctx).Error("Store operation failed", zap.String("operation", op), zap.Error(failure))
+		}
+	}
+}
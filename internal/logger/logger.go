@@ -1,26 +1,96 @@
 package logger
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Log — глобальный логгер приложения. Инициализируется в Initialize.
 var Log *zap.Logger = zap.NewNop()
 
+// level — уровень глобального логгера, изменяемый без перезапуска.
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+// Форматы записей лога.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Параметры сэмплирования: в каждую секунду пишутся первые samplingInitial
+// одинаковых записей, затем каждая samplingThereafter-я.
+const (
+	samplingInitial    = 100
+	samplingThereafter = 100
+)
+
+// Options — параметры глобального логгера.
+type Options struct {
+	// Level — минимальный уровень записей: debug, info, warn, error.
+	Level string
+	// Format — формат записей: json или console.
+	Format string
+	// Sampling — прореживать повторяющиеся записи под нагрузкой.
+	Sampling bool
+	// File — файл лога; пустое значение означает stderr.
+	File string
+	// MaxSizeMB — размер файла в мегабайтах, после которого он ротируется;
+	// 0 отключает ротацию.
+	MaxSizeMB int
+	// MaxBackups — сколько ротированных файлов хранить.
+	MaxBackups int
+}
+
 // Initialize настраивает глобальный логгер по заданному уровню логирования.
 func Initialize(level string) error {
-	lvl, err := zap.ParseAtomicLevel(level)
-	if err != nil {
-		return err
+	return Configure(Options{Level: level})
+}
+
+// Configure настраивает глобальный логгер по параметрам opts.
+func Configure(opts Options) error {
+	lvl := zapcore.InfoLevel
+	if opts.Level != "" {
+		var err error
+		if lvl, err = zapcore.ParseLevel(opts.Level); err != nil {
+			return err
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch opts.Format {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return fmt.Errorf("unknown log format %q: expected %s or %s", opts.Format, FormatJSON, FormatConsole)
 	}
 
-	cfg := zap.NewProductionConfig()
-	cfg.Level = lvl
-	zl, err := cfg.Build()
-	if err != nil {
-		return err
+	var sink zapcore.WriteSyncer = zapcore.Lock(os.Stderr)
+	if opts.File != "" {
+		file, err := newRotatingFile(opts.File, int64(opts.MaxSizeMB)<<20, opts.MaxBackups)
+		if err != nil {
+			return err
+		}
+		sink = file
 	}
 
-	Log = zl
+	core := zapcore.NewCore(encoder, sink, level)
+	if opts.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, samplingInitial, samplingThereafter)
+	}
+
+	level.SetLevel(lvl)
+	Log = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 	return nil
 }
+
+// Level возвращает уровень глобального логгера. Его можно менять на лету
+// через SetLevel или HTTP-обработчик AtomicLevel.
+func Level() zap.AtomicLevel {
+	return level
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Sync())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestConfigure(t *testing.T) {
+	prev, prevLevel := Log, level.Level()
+	t.Cleanup(func() {
+		Log = prev
+		level.SetLevel(prevLevel)
+	})
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, Configure(Options{Level: "warn", Format: FormatConsole, File: path}))
+	Log.Info("hidden")
+	Log.Warn("shown")
+
+	Level().SetLevel(zapcore.DebugLevel)
+	Log.Debug("debug after change")
+	require.NoError(t, Log.Sync())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hidden")
+	assert.Contains(t, string(data), "shown")
+	assert.Contains(t, string(data), "debug after change")
+	assert.False(t, strings.HasPrefix(string(data), "{"), "console format expected")
+
+	assert.Error(t, Configure(Options{Level: "loud"}))
+	assert.Error(t, Configure(Options{Format: "xml"}))
+}
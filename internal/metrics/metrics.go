@@ -0,0 +1,176 @@
+// Package metrics собирает метрики сервиса и отдаёт их в текстовом формате Prometheus.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+)
+
+// namespace — общий префикс имён метрик сервиса.
+const namespace = "shortener"
+
+// Результаты перехода по короткой ссылке для метрики redirects_total.
+const (
+	RedirectOK       = "redirect"
+	RedirectNotFound = "not_found"
+	RedirectDeleted  = "deleted"
+	RedirectDisabled = "disabled"
+)
+
+// Metrics хранит собственный реестр и метрики сервиса. Методы безопасны
+// для вызова на nil, поэтому код может записывать метрики без проверки,
+// включены ли они.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	storeDuration   *prometheus.HistogramVec
+	storeErrors     *prometheus.CounterVec
+	redirects       *prometheus.CounterVec
+	deleteQueue     prometheus.Gauge
+	buildInfo       *prometheus.GaugeVec
+	configInfo      *prometheus.GaugeVec
+	startTime       prometheus.Gauge
+}
+
+// New создаёт набор метрик со своим реестром, в который также входят
+// метрики среды выполнения Go и процесса.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Количество HTTP-запросов по маршруту, методу и коду ответа.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Длительность операций хранилища.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		storeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "store_operation_errors_total",
+			Help:      "Количество сбоев операций хранилища.",
+		}, []string{"operation"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Количество переходов по коротким ссылкам по результату.",
+		}, []string{"result"}),
+		deleteQueue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "delete_queue_depth",
+			Help:      "Количество запросов на удаление ссылок, ожидающих асинхронной обработки.",
+		}),
+		buildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "build_info",
+			Help:      "Сведения о сборке и хранилище; значение всегда 1.",
+		}, []string{"version", "commit", "build_date", "go_version", "store_backend"}),
+		configInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_info",
+			Help:      "Отпечаток действующей конфигурации; значение всегда 1.",
+		}, []string{"fingerprint"}),
+		startTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "start_time_seconds",
+			Help:      "Время запуска процесса в секундах Unix.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.requestDuration,
+		m.storeDuration, m.storeErrors,
+		m.redirects, m.deleteQueue,
+		m.buildInfo, m.configInfo, m.startTime,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Registry возвращает реестр метрик для регистрации дополнительных коллекторов.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler возвращает обработчик, отдающий метрики в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest учитывает обработанный HTTP-запрос. route — шаблон маршрута chi.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// StoreHook — хук store.ObservedStore, измеряющий длительность операций хранилища и считающий сбои.
+func (m *Metrics) StoreHook(ctx context.Context, op string) (context.Context, func(failure error)) {
+	if m == nil {
+		return ctx, func(error) {}
+	}
+	start := time.Now()
+	return ctx, func(failure error) {
+		m.storeDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+		if failure != nil {
+			m.storeErrors.WithLabelValues(op).Inc()
+		}
+	}
+}
+
+// ObserveRedirect учитывает переход по короткой ссылке с результатом result.
+func (m *Metrics) ObserveRedirect(result string) {
+	if m == nil {
+		return
+	}
+	m.redirects.WithLabelValues(result).Inc()
+}
+
+// SetBuildInfo публикует сведения о сборке info, название хранилища backend
+// и время запуска процесса started.
+func (m *Metrics) SetBuildInfo(info buildinfo.Info, backend string, started time.Time) {
+	if m == nil {
+		return
+	}
+	m.buildInfo.Reset()
+	m.buildInfo.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion, backend).Set(1)
+	m.startTime.Set(float64(started.Unix()))
+}
+
+// SetConfigFingerprint публикует отпечаток действующей конфигурации.
+func (m *Metrics) SetConfigFingerprint(fingerprint string) {
+	if m == nil {
+		return
+	}
+	m.configInfo.Reset()
+	m.configInfo.WithLabelValues(fingerprint).Set(1)
+}
+
+// DeleteQueueAdd изменяет глубину очереди асинхронного удаления на delta.
+func (m *Metrics) DeleteQueueAdd(delta int) {
+	if m == nil {
+		return
+	}
+	m.deleteQueue.Add(float64(delta))
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/buildinfo"
+)
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveRequest("GET", "/{shortURL}", 307, time.Millisecond)
+		m.ObserveRedirect(RedirectOK)
+		m.SetBuildInfo(buildinfo.Info{}, "memory", time.Now())
+		m.SetConfigFingerprint("ff")
+		m.DeleteQueueAdd(1)
+
+		ctx := context.Background()
+		hookCtx, done := m.StoreHook(ctx, "Save")
+		assert.Equal(t, ctx, hookCtx)
+		done(nil)
+		done(errors.New("boom"))
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+)
+
+// RequireAdmin пропускает только запросы администраторов и отвечает 403
+// остальным. Должен стоять после AuthMiddleware.
+func RequireAdmin(isAdmin func(auth.Identity) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := IdentityFromContext(r.Context())
+			if !ok || !isAdmin(identity) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/logger"
+)
+
+type contextKey string
+
+const (
+	// UserIDKey — ключ контекста для хранения идентификатора пользователя.
+	UserIDKey contextKey = "user_id"
+	// IdentityKey — ключ контекста для хранения auth.Identity пользователя.
+	IdentityKey contextKey = "identity"
+)
+
+// IdentityFromContext возвращает личность пользователя, установленную AuthMiddleware.
+func IdentityFromContext(ctx context.Context) (auth.Identity, bool) {
+	identity, ok := ctx.Value(IdentityKey).(auth.Identity)
+	return identity, ok
+}
+
+// AuthMiddleware аутентифицирует запрос цепочкой аутентификаторов и прокидывает
+// идентификатор пользователя в контекст запроса.
+//
+// Учётные данные проверяются в порядке API-ключ → JWT → сессия → cookie
+// user_id: первый аутентификатор, нашедший их, определяет пользователя.
+// Если учётные данные присутствуют, но неверны, запрос отклоняется с кодом
+// 401, а при внутренней ошибке проверки — с кодом 500. Заголовок
+// WWW-Authenticate ставится, только если отказавший аутентификатор
+// реализует auth.Challenger. Если cookie user_id нет или её подпись неверна,
+// выдаётся новый анонимный идентификатор.
+// Cookie, подписанные неактивным ключом, перевыпускаются активным.
+func AuthMiddleware(signer *auth.CookieSigner, authenticators ...auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, failed, err := authenticate(r, authenticators)
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials):
+				identity = cookieIdentity(w, r, signer)
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				logger.FromContext(r.Context()).Info("Authentication failed", zap.Error(err))
+				if c, ok := failed.(auth.Challenger); ok {
+					w.Header().Set("WWW-Authenticate", c.Challenge())
+				}
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			case err != nil:
+				logger.FromContext(r.Context()).Error("Authentication error", zap.Error(err))
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+
+			setAccessLogUserID(r, identity.UserID)
+			ctx := context.WithValue(r.Context(), UserIDKey, identity.UserID)
+			ctx = context.WithValue(ctx, IdentityKey, identity)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticate возвращает результат первого аутентификатора, нашедшего
+// учётные данные, и сам этот аутентификатор.
+func authenticate(r *http.Request, authenticators []auth.Authenticator) (auth.Identity, auth.Authenticator, error) {
+	for _, a := range authenticators {
+		identity, err := a.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) {
+			continue
+		}
+		return identity, a, err
+	}
+	return auth.Identity{}, nil, auth.ErrNoCredentials
+}
+
+// cookieIdentity возвращает пользователя из cookie user_id, при необходимости
+// выпуская новую cookie.
+func cookieIdentity(w http.ResponseWriter, r *http.Request, signer *auth.CookieSigner) auth.Identity {
+	cookie, _ := r.Cookie(auth.CookieName)
+	userID, rotate, ok := signer.ValidateCookie(cookie)
+	if !ok {
+		userID = auth.GenerateUserID()
+		rotate = true
+	}
+	if rotate {
+		http.SetCookie(w, signer.GenerateCookie(userID))
+	}
+	return auth.Identity{UserID: userID, Method: auth.MethodCookie}
+}
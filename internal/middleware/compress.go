@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressMinSize — размер ответа, начиная с которого он сжимается.
+// Меньшие ответы от сжатия почти не выигрывают.
+const DefaultCompressMinSize = 1024
+
+// Поддерживаемые кодировки содержимого.
+const (
+	encodingBrotli  = "br"
+	encodingZstd    = "zstd"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	encodingIdent   = "identity"
+)
+
+// serverPreference — порядок выбора кодировки при равных q-значениях клиента.
+var serverPreference = []string{encodingBrotli, encodingZstd, encodingGzip, encodingDeflate}
+
+// encoder — сжимающий writer, который можно переиспользовать через Reset.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools хранит сжимающие writer'ы каждой кодировки для повторного использования.
+var encoderPools = map[string]*sync.Pool{
+	encodingBrotli: {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	encodingZstd: {New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+		return enc
+	}},
+	encodingGzip:    {New: func() any { return gzip.NewWriter(nil) }},
+	encodingDeflate: {New: func() any { return zlib.NewWriter(nil) }},
+}
+
+// Compress сжимает ответы в кодировке, выбранной по Accept-Encoding с учётом
+// q-значений (br, zstd, gzip или deflate), и распаковывает тела запросов с
+// Content-Encoding в любой из этих кодировок. Ответы меньше minSize байт,
+// ответы без тела (1xx, 204, 304, HEAD), уже сжатые и несжимаемые по
+// Content-Type ответы отправляются как есть.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Encoding") != "" {
+				body, err := decodeBody(r.Body, r.Header.Values("Content-Encoding"))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+					return
+				}
+				defer body.Close()
+				r.Body = body
+				r.ContentLength = -1
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding выбирает кодировку ответа по заголовку Accept-Encoding.
+// Пустая строка означает ответ без сжатия.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	q := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					weight = parsed
+				} else {
+					weight = 0
+				}
+			}
+		}
+		switch name {
+		case "*":
+			wildcard = weight
+		case "x-gzip":
+			q[encodingGzip] = weight
+		default:
+			q[name] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range serverPreference {
+		weight, ok := q[encoding]
+		if !ok {
+			weight = wildcard
+		}
+		if weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressWriter накапливает начало ответа, пока не станет ясно, стоит ли
+// его сжимать, и затем пишет его либо через encoder, либо как есть.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	enc     encoder
+}
+
+// WriteHeader запоминает код ответа; он отправляется вместе с решением о сжатии.
+func (c *compressWriter) WriteHeader(status int) {
+	if c.decided || c.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		// информационные ответы не влияют на решение о сжатии
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
+	c.status = status
+	if !bodyAllowed(status) {
+		c.decide(false)
+	}
+}
+
+// Write буферизует ответ до minSize байт, после чего начинает сжатие.
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if !c.decided {
+		c.buf.Write(p)
+		if c.buf.Len() == 0 || c.buf.Len() < c.minSize {
+			return len(p), nil
+		}
+		if err := c.decide(c.compressible()); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.enc != nil {
+		return c.enc.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Flush принимает решение о сжатии по уже записанным данным и отправляет их клиенту.
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide(c.buf.Len() > 0 && c.buf.Len() >= c.minSize && c.compressible())
+	}
+	if c.enc != nil {
+		c.enc.Flush()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack передаёт соединение обработчику, если это поддерживает исходный writer.
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := c.ResponseWriter.(http.Hijacker); ok {
+		c.decided = true
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("hijacking not supported")
+}
+
+// Close отправляет недописанный ответ и возвращает encoder в пул.
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if c.status == 0 && c.buf.Len() == 0 {
+			return nil
+		}
+		c.decide(c.buf.Len() > 0 && c.buf.Len() >= c.minSize && c.compressible())
+	}
+	if c.enc == nil {
+		return nil
+	}
+	err := c.enc.Close()
+	c.enc.Reset(nil)
+	encoderPools[c.encoding].Put(c.enc)
+	c.enc = nil
+	return err
+}
+
+// decide отправляет заголовки и буфер, включая сжатие, если compress истинно.
+func (c *compressWriter) decide(compress bool) error {
+	c.decided = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if compress {
+		h := c.Header()
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+		c.enc = encoderPools[c.encoding].Get().(encoder)
+		c.enc.Reset(c.ResponseWriter)
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if c.enc != nil {
+		_, err = c.enc.Write(c.buf.Bytes())
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf.Bytes())
+	}
+	c.buf.Reset()
+	return err
+}
+
+// compressible сообщает, имеет ли смысл сжимать ответ с текущими заголовками.
+func (c *compressWriter) compressible() bool {
+	h := c.Header()
+	if !bodyAllowed(c.status) || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(c.buf.Bytes())
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// bodyAllowed сообщает, может ли ответ с кодом status содержать тело.
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// decodeBody оборачивает тело запроса распаковщиками в порядке, обратном
+// применённым кодировкам.
+func decodeBody(body io.ReadCloser, contentEncoding []string) (io.ReadCloser, error) {
+	var encodings []string
+	for _, value := range contentEncoding {
+		for _, encoding := range strings.Split(value, ",") {
+			if encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding != "" && encoding != encodingIdent {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+
+	reader := &decodedBody{body: body}
+	var r io.Reader = body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encodings[i] {
+		case encodingGzip, "x-gzip":
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			reader.closers = append(reader.closers, zr.Close)
+			r = zr
+		case encodingDeflate:
+			zr, err := zlib.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			reader.closers = append(reader.closers, zr.Close)
+			r = zr
+		case encodingBrotli:
+			r = brotli.NewReader(r)
+		case encodingZstd:
+			zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			reader.closers = append(reader.closers, func() error { zr.Close(); return nil })
+			r = zr
+		default:
+			return nil, errors.New("unsupported content encoding: " + encodings[i])
+		}
+	}
+	reader.Reader = r
+	return reader, nil
+}
+
+// decodedBody — распакованное тело запроса, закрывающее распаковщики и исходное тело.
+type decodedBody struct {
+	io.Reader
+	body    io.ReadCloser
+	closers []func() error
+}
+
+// Close закрывает распаковщики и исходное тело запроса.
+func (d *decodedBody) Close() error {
+	for _, closeFn := range d.closers {
+		closeFn()
+	}
+	return d.body.Close()
+}
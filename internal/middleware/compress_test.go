@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0, gzip;q=0.2, zstd;q=0.8", "zstd"},
+		{"*", "br"},
+		{"*;q=0.5, br;q=0", "zstd"},
+		{"identity", ""},
+		{"gzip;q=0", ""},
+		{"x-gzip", "gzip"},
+		{"DEFLATE;Q=0.9", "deflate"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiateEncoding(tt.accept), tt.accept)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"short_url":"http://localhost:8080/abc"}`, 100)
+	handler := Compress(256)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-modified":
+			w.WriteHeader(http.StatusNotModified)
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(large))
+		case "/echo":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			for i := 0; i < len(large); i += 100 {
+				w.Write([]byte(large[i:min(i+100, len(large))]))
+			}
+		}
+	}))
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept-Encoding", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		"br":      func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd":    func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			// повторный запрос берёт encoder из пула
+			for range 2 {
+				w := get("/", encoding)
+				require.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+				zr, err := decode(w.Body)
+				require.NoError(t, err)
+				body, err := io.ReadAll(zr)
+				require.NoError(t, err)
+				assert.Equal(t, large, string(body))
+			}
+		})
+	}
+
+	for _, path := range []string{"/empty", "/not-modified", "/small", "/image"} {
+		w := get(path, "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"), path)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), path)
+	}
+	assert.Equal(t, http.StatusNoContent, get("/empty", "gzip").Code)
+	assert.Equal(t, `{}`, get("/small", "gzip").Body.String())
+	assert.Empty(t, get("/", "identity").Header().Get("Content-Encoding"))
+
+	t.Run("request", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(enc)
+		gz.Write([]byte("https://example.com"))
+		require.NoError(t, gz.Close())
+		require.NoError(t, enc.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/echo", &buf)
+		r.Header.Set("Content-Encoding", "gzip, zstd")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "https://example.com", w.Body.String())
+
+		r = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("plain"))
+		r.Header.Set("Content-Encoding", "compress")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
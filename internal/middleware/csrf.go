@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+)
+
+const (
+	// CSRFCookieName — cookie с CSRF-токеном. Она доступна скриптам страницы,
+	// чтобы они могли повторить токен в заголовке CSRFHeader.
+	CSRFCookieName = "csrf_token"
+	// CSRFHeader — заголовок запроса с CSRF-токеном; он же возвращается в
+	// каждом ответе.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// csrfPurpose — назначение подписи CSRF-токена.
+const csrfPurpose = "csrf"
+
+// CSRF защищает изменяющие запросы (POST, PUT, PATCH, DELETE), пользователь
+// которых определён по cookie, методом double-submit: значение заголовка
+// X-CSRF-Token должно совпадать с cookie csrf_token, подписанной signer.
+// Запросы с bearer-токеном или API-ключом не проверяются: чужой сайт не может
+// подставить эти заголовки. Должен стоять после AuthMiddleware.
+func CSRF(signer *auth.CookieSigner) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := ""
+			if cookie, err := r.Cookie(CSRFCookieName); err == nil {
+				if _, _, ok := signer.Verify(csrfPurpose, cookie.Value); ok {
+					token = cookie.Value
+				}
+			}
+
+			if !safeMethod(r.Method) && cookieAuthenticated(r) {
+				header := r.Header.Get(CSRFHeader)
+				if token == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+					http.Error(w, "CSRF token missing or invalid", http.StatusForbidden)
+					return
+				}
+			}
+
+			if token == "" {
+				token = signer.Sign(csrfPurpose, newCSRFValue())
+				cookie := signer.Cookie(CSRFCookieName, token, 0)
+				cookie.HttpOnly = false
+				http.SetCookie(w, cookie)
+			}
+			w.Header().Set(CSRFHeader, token)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// safeMethod сообщает, что метод не изменяет состояние.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cookieAuthenticated сообщает, что пользователь запроса определён по cookie,
+// которую браузер мог приложить к подделанному запросу.
+func cookieAuthenticated(r *http.Request) bool {
+	identity, ok := IdentityFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	switch identity.Method {
+	case auth.MethodSession:
+		return true
+	case auth.MethodCookie:
+		// новый анонимный пользователь не может пострадать от подделки запроса
+		_, err := r.Cookie(auth.CookieName)
+		return err == nil
+	}
+	return false
+}
+
+// newCSRFValue генерирует случайное значение CSRF-токена.
+func newCSRFValue() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Timeout ограничивает время работы обработчика. Если он не успел ответить
+// за d, клиент получает 503, а контекст запроса отменяется. При d <= 0
+// ограничение не применяется.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.TimeoutHandler(next, d, "Request timed out")
+	}
+}
+
+// BodyLimit ограничивает размер тела запроса n байтами. Запросы с заведомо
+// большим Content-Length сразу получают 413, а чтение сверх лимита у
+// остальных завершается ошибкой, которую распознаёт IsBodyTooLarge.
+// При n <= 0 ограничение не применяется.
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsBodyTooLarge сообщает, что err вызвана превышением лимита BodyLimit.
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// unmatchedRoute — метка маршрута для запросов, не попавших ни в один маршрут.
+const unmatchedRoute = "unmatched"
+
+// RequestObserver получает сведения об обработанном запросе.
+type RequestObserver interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
+// Metrics передаёт observer метод, шаблон маршрута chi, код ответа и длительность
+// каждого запроса. Шаблон вместо пути не даёт числу меток расти с числом ссылок.
+func Metrics(observer RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			responseData := &responseData{}
+			next.ServeHTTP(&loggingResponseWriter{ResponseWriter: w, responseData: responseData}, r)
+
+			route := routePattern(r)
+			status := responseData.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			observer.ObserveRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
+
+// routePattern возвращает шаблон маршрута chi, которым был обработан запрос.
+// Вызывается после обработки запроса, когда маршрут уже известен.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return unmatchedRoute
+}
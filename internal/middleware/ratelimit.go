@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/logger"
+	"github.com/AlexeySalamakhin/URLShortener/internal/ratelimit"
+)
+
+// RateLimit ограничивает частоту запросов группы group отдельно для каждого
+// пользователя и для каждого адреса клиента. Превысившим лимит отвечает 429
+// с заголовком Retry-After; всем ответам добавляет заголовки RateLimit-*.
+// Должен стоять после AuthMiddleware. Если хранилище лимитов недоступно,
+// запрос пропускается.
+func RateLimit(limiter *ratelimit.Limiter, group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter.Limit(group).Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keys := []string{"ip:" + limiter.ClientIP(r)}
+			if userID, ok := r.Context().Value(UserIDKey).(string); ok && userID != "" {
+				keys = append(keys, "user:"+userID)
+			}
+
+			var strictest ratelimit.Result
+			for i, key := range keys {
+				res, err := limiter.Take(r.Context(), group, key)
+				if err != nil {
+					logger.FromContext(r.Context()).Error("Rate limiter unavailable", zap.String("group", group), zap.Error(err))
+					next.ServeHTTP(w, r)
+					return
+				}
+				if i == 0 || !res.Allowed || (strictest.Allowed && res.Remaining < strictest.Remaining) {
+					strictest = res
+				}
+				if !res.Allowed {
+					break
+				}
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(strictest.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(strictest.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(strictest.Reset))
+			if !strictest.Allowed {
+				h.Set("Retry-After", ceilSeconds(strictest.RetryAfter))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/logger"
+)
+
+// Recover перехватывает панику обработчика, пишет её в лог со стеком вызовов
+// и отвечает клиенту JSON с кодом 500. Паника http.ErrAbortHandler
+// пробрасывается дальше: ею обработчик намеренно обрывает соединение.
+// Если обработчик успел начать ответ, дописывать в него нечего, и соединение
+// тоже обрывается паникой http.ErrAbortHandler.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		written := &responseData{}
+		lw := &loggingResponseWriter{ResponseWriter: w, responseData: written}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			logger.FromContext(r.Context()).Error("Handler panic",
+				zap.String("panic", fmt.Sprint(rec)),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Stack("stack"),
+			)
+			if written.status != 0 || written.size > 0 {
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"internal server error"}`))
+		}()
+		next.ServeHTTP(lw, r)
+	})
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -9,7 +10,9 @@ import (
 	"github.com/AlexeySalamakhin/URLShortener/internal/logger"
 )
 
-// RequestLogger логирует входящие HTTP-запросы и исходящие ответы с длительностью.
+// RequestLogger пишет одну запись журнала доступа на каждый запрос: метод,
+// путь, шаблон маршрута, статус, размер ответа, длительность, пользователь
+// и адрес клиента. Должен стоять после RequestID.
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -22,21 +25,43 @@ func RequestLogger(next http.Handler) http.Handler {
 			ResponseWriter: w,
 			responseData:   responseData,
 		}
-		next.ServeHTTP(&lw, r)
+		entry := &accessLog{}
+		next.ServeHTTP(&lw, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
 		duration := time.Since(start)
 
-		logger.Log.Info("HTTP request",
+		status := responseData.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.FromContext(r.Context()).Info("HTTP request",
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
-			zap.Duration("duration", duration),
-		)
-		logger.Log.Info("HTTP response",
-			zap.Int("status code", responseData.status),
+			zap.String("route", routePattern(r)),
+			zap.Int("status", status),
 			zap.Int("size", responseData.size),
+			zap.Duration("duration", duration),
+			zap.String("user_id", entry.userID),
+			zap.String("remote_addr", r.RemoteAddr),
 		)
 	})
 }
 
+// accessLogKey — ключ контекста с записью журнала доступа текущего запроса.
+type accessLogKey struct{}
+
+// accessLog — сведения о запросе, которые становятся известны внутри цепочки
+// middleware и нужны RequestLogger.
+type accessLog struct {
+	userID string
+}
+
+// setAccessLogUserID запоминает пользователя запроса для журнала доступа.
+func setAccessLogUserID(r *http.Request, userID string) {
+	if entry, ok := r.Context().Value(accessLogKey{}).(*accessLog); ok {
+		entry.userID = userID
+	}
+}
+
 type (
 	responseData struct {
 		status int
@@ -61,3 +86,8 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.ResponseWriter.WriteHeader(statusCode)
 	r.responseData.status = statusCode
 }
+
+// Unwrap возвращает исходный http.ResponseWriter для http.ResponseController.
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
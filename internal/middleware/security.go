@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecurityHeaders добавляет к ответам стандартные заголовки безопасности.
+// При hstsMaxAge > 0 добавляется Strict-Transport-Security; его следует
+// включать только для сервиса, доступного по HTTPS.
+func SecurityHeaders(hstsMaxAge time.Duration) func(http.Handler) http.Handler {
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+			h.Set("Cross-Origin-Opener-Policy", "same-origin")
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORSOptions — политика межсайтовых запросов.
+type CORSOptions struct {
+	// AllowedOrigins — разрешённые источники; "*" разрешает любой источник,
+	// но без передачи cookie.
+	AllowedOrigins []string
+	// AllowedMethods — методы, разрешённые в межсайтовых запросах.
+	AllowedMethods []string
+	// AllowedHeaders — заголовки запроса, разрешённые в межсайтовых запросах.
+	AllowedHeaders []string
+	// ExposedHeaders — заголовки ответа, доступные скрипту.
+	ExposedHeaders []string
+	// AllowCredentials — разрешить передачу cookie и заголовка Authorization.
+	AllowCredentials bool
+	// MaxAge — сколько браузер может кэшировать ответ на preflight-запрос.
+	MaxAge time.Duration
+}
+
+// CORS применяет политику межсайтовых запросов opts: отвечает на
+// preflight-запросы и добавляет заголовки Access-Control-* к ответам для
+// разрешённых источников. Запросы других источников обрабатываются без
+// этих заголовков, и браузер не отдаёт ответ скрипту.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	opts.AllowedOrigins = trimList(opts.AllowedOrigins)
+	opts.AllowedMethods = trimList(opts.AllowedMethods)
+	opts.AllowedHeaders = trimList(opts.AllowedHeaders)
+	opts.ExposedHeaders = trimList(opts.ExposedHeaders)
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	allowedMethods := strings.ToUpper(strings.Join(opts.AllowedMethods, ", "))
+	allowedHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	originAllowed := func(origin string) bool {
+		return anyOrigin || slices.Contains(opts.AllowedOrigins, origin)
+	}
+	methodAllowed := func(method string) bool {
+		return slices.ContainsFunc(opts.AllowedMethods, func(m string) bool {
+			return strings.EqualFold(m, method)
+		})
+	}
+	allowOrigin := func(h http.Header, origin string) {
+		// с cookie браузер не принимает "*", поэтому источник возвращается явно
+		if anyOrigin && !opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", origin)
+		if opts.AllowCredentials && !anyOrigin {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method == http.MethodOptions && requestMethod != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				if originAllowed(origin) && methodAllowed(requestMethod) {
+					allowOrigin(h, origin)
+					h.Set("Access-Control-Allow-Methods", allowedMethods)
+					if allowedHeaders != "" {
+						h.Set("Access-Control-Allow-Headers", allowedHeaders)
+					}
+					if opts.MaxAge > 0 {
+						h.Set("Access-Control-Max-Age", maxAge)
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if originAllowed(origin) {
+				allowOrigin(h, origin)
+				if exposedHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trimList убирает пробелы вокруг элементов списка и пустые элементы.
+func trimList(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // URLRecord представляет запись URL в хранилище.
 type URLRecord struct {
 	UUID        string `json:"uuid"`
@@ -7,6 +12,11 @@ type URLRecord struct {
 	OriginalURL string `json:"original_url"`
 	UserID      string `json:"user_id"`
 	DeletedFlag bool   `json:"is_deleted"`
+	// Disabled — ссылка отключена администратором и не выполняет редирект.
+	Disabled bool `json:"is_disabled,omitempty"`
+	// TeamID — рабочее пространство, которому принадлежит ссылка; для личных
+	// ссылок пусто. У ссылок команды UserID — это автор ссылки.
+	TeamID string `json:"team_id,omitempty"`
 }
 
 // ShortenRequest — запрос на сокращение URL.
@@ -15,8 +25,47 @@ type ShortenRequest struct {
 }
 
 // ShortenResponse — ответ с результатом сокращения URL.
+// Поле Owned заполняется только при конфликте и показывает,
+// принадлежит ли существующая ссылка текущему пользователю.
 type ShortenResponse struct {
 	Result string `json:"result"`
+	Owned  *bool  `json:"owned,omitempty"`
+}
+
+// ShortenResult — результат сокращения URL на уровне сервиса.
+type ShortenResult struct {
+	// ShortURL — короткий ключ ссылки.
+	ShortURL string
+	// Conflict — true, если ссылка уже существовала.
+	Conflict bool
+	// Owned — true, если ссылка принадлежит пользователю, выполнившему запрос.
+	Owned bool
+}
+
+// DedupScope определяет область, в пределах которой повторное сокращение
+// одного и того же URL возвращает уже существующий короткий ключ.
+type DedupScope string
+
+const (
+	// DedupGlobal — один короткий ключ на URL для всех пользователей.
+	DedupGlobal DedupScope = "global"
+	// DedupUser — у каждого пользователя свой короткий ключ для URL.
+	DedupUser DedupScope = "user"
+	// DedupNone — дедупликация отключена, каждый запрос создаёт новую ссылку.
+	DedupNone DedupScope = "none"
+)
+
+// ParseDedupScope разбирает строковое значение области дедупликации.
+// Пустая строка трактуется как DedupGlobal.
+func ParseDedupScope(s string) (DedupScope, error) {
+	switch DedupScope(s) {
+	case "", DedupGlobal:
+		return DedupGlobal, nil
+	case DedupUser, DedupNone:
+		return DedupScope(s), nil
+	default:
+		return "", fmt.Errorf("unknown dedup scope %q", s)
+	}
 }
 
 // ShortURLBatchRequest — пакет запросов на сокращение URL.
@@ -39,4 +88,178 @@ type UserURLsResponse struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
 	DeletedFlag bool   `json:"is_deleted"`
+	Disabled    bool   `json:"is_disabled,omitempty"`
+}
+
+// URLFilter — условия поиска ссылок администратором.
+type URLFilter struct {
+	// Query — подстрока короткого ключа или исходного URL (без учёта регистра).
+	Query string
+	// UserID — владелец ссылок; пустое значение означает любого владельца.
+	UserID string
+	// IncludeDeleted — включать ли удалённые ссылки.
+	IncludeDeleted bool
+	// Limit и Offset задают страницу результатов; записи упорядочены по короткому ключу.
+	Limit  int
+	Offset int
+}
+
+// AdminURLResponse — DTO ссылки в административном API.
+type AdminURLResponse struct {
+	Key         string `json:"key"`
+	ShortURL    string `json:"short_url"`
+	OriginalURL string `json:"original_url"`
+	UserID      string `json:"user_id"`
+	DeletedFlag bool   `json:"is_deleted"`
+	Disabled    bool   `json:"is_disabled"`
+	TeamID      string `json:"team_id,omitempty"`
+}
+
+// AdminURLUpdate — изменение ссылки администратором. Незаданные поля не меняются.
+type AdminURLUpdate struct {
+	Disabled *bool   `json:"disabled,omitempty"`
+	UserID   *string `json:"user_id,omitempty"`
+}
+
+// APIKey — персональный API-ключ пользователя в хранилище.
+// Сам ключ не хранится, только его SHA-256 хэш.
+type APIKey struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAPIKeyRequest — запрос на создание API-ключа.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// APIKeyResponse — DTO для вывода API-ключа. Поле Key заполняется
+// только в ответе на создание ключа.
+type APIKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+	Key       string    `json:"key,omitempty"`
+}
+
+// User — зарегистрированная учётная запись пользователя.
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session — сессия вошедшего пользователя. Токен сессии не хранится, только его хэш.
+type Session struct {
+	Hash      string    `json:"hash"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CredentialsRequest — запрос на регистрацию или вход в учётную запись.
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// ClaimAnonymous — перенести в учётную запись ссылки, созданные
+	// под текущим анонимным идентификатором.
+	ClaimAnonymous bool `json:"claim_anonymous"`
+}
+
+// AccountResponse — ответ на регистрацию или вход.
+type AccountResponse struct {
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	Claimed int    `json:"claimed_urls"`
+}
+
+// TeamRole — роль участника рабочего пространства.
+type TeamRole string
+
+const (
+	// TeamRoleAdmin — администратор: приглашает и удаляет участников,
+	// редактирует и удаляет любые ссылки команды.
+	TeamRoleAdmin TeamRole = "admin"
+	// TeamRoleMember — участник: сокращает ссылки в пространство, видит все
+	// ссылки команды и редактирует только свои.
+	TeamRoleMember TeamRole = "member"
+)
+
+// ParseTeamRole разбирает роль участника. Пустая строка трактуется как TeamRoleMember.
+func ParseTeamRole(s string) (TeamRole, error) {
+	switch TeamRole(s) {
+	case "", TeamRoleMember:
+		return TeamRoleMember, nil
+	case TeamRoleAdmin:
+		return TeamRoleAdmin, nil
+	default:
+		return "", fmt.Errorf("unknown team role %q", s)
+	}
+}
+
+// Team — рабочее пространство, в котором участники совместно владеют ссылками.
+type Team struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TeamMember — участие пользователя в рабочем пространстве.
+type TeamMember struct {
+	TeamID   string    `json:"team_id"`
+	UserID   string    `json:"user_id"`
+	Role     TeamRole  `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// TeamInvite — приглашение в рабочее пространство. Токен приглашения
+// не хранится, только его хэш.
+type TeamInvite struct {
+	Hash      string    `json:"hash"`
+	TeamID    string    `json:"team_id"`
+	Role      TeamRole  `json:"role"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateTeamRequest — запрос на создание рабочего пространства.
+type CreateTeamRequest struct {
+	Name string `json:"name"`
+}
+
+// TeamResponse — рабочее пространство и роль в нём текущего пользователя.
+type TeamResponse struct {
+	ID   string   `json:"id"`
+	Name string   `json:"name"`
+	Role TeamRole `json:"role"`
+}
+
+// CreateInviteRequest — запрос на создание приглашения.
+type CreateInviteRequest struct {
+	Role string `json:"role"`
+}
+
+// TeamInviteResponse — выданное приглашение. Токен возвращается только один раз.
+type TeamInviteResponse struct {
+	Token     string    `json:"token"`
+	Role      TeamRole  `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// JoinTeamRequest — запрос на вступление в рабочее пространство по приглашению.
+type JoinTeamRequest struct {
+	Token string `json:"token"`
+}
+
+// TeamURLResponse — DTO ссылки рабочего пространства.
+type TeamURLResponse struct {
+	ShortURL    string `json:"short_url"`
+	OriginalURL string `json:"original_url"`
+	CreatedBy   string `json:"created_by"`
 }
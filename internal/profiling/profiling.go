@@ -0,0 +1,168 @@
+// Package profiling периодически снимает CPU- и heap-профили процесса
+// и сохраняет их в каталог для последующего сравнения через go tool pprof.
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+)
+
+// Виды профилей; используются как префикс имени файла.
+const (
+	KindCPU  = "cpu"
+	KindHeap = "heap"
+)
+
+// timeFormat — формат времени в имени файла; лексикографический порядок
+// имён совпадает с хронологическим.
+const timeFormat = "20060102T150405Z"
+
+// Options — настройки непрерывного профилирования.
+type Options struct {
+	// Dir — каталог для профилей; создаётся при необходимости.
+	Dir string
+	// Interval — период между снятиями профилей.
+	Interval time.Duration
+	// CPUDuration — длительность снятия CPU-профиля, меньше Interval.
+	CPUDuration time.Duration
+	// Keep — сколько последних профилей каждого вида хранить.
+	Keep int
+}
+
+// Profiler снимает профили по расписанию.
+type Profiler struct {
+	opts Options
+	now  func() time.Time
+}
+
+// New создаёт Profiler и каталог для профилей.
+func New(opts Options) (*Profiler, error) {
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create profile dir: %w", err)
+	}
+	return &Profiler{opts: opts, now: time.Now}, nil
+}
+
+// Run снимает профили каждые Interval до отмены ctx. Ошибки отдельных
+// снятий логируются и не прерывают работу.
+func (p *Profiler) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Capture(ctx); err != nil && ctx.Err() == nil {
+				logger.Log.Error("Failed to capture profiles", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Capture снимает CPU-профиль длительностью CPUDuration и профиль кучи,
+// после чего удаляет профили сверх Keep. CPU-профиль не снимается, если
+// в это время уже идёт другое профилирование CPU, например через
+// /debug/pprof/profile; профиль кучи при этом всё равно сохраняется.
+func (p *Profiler) Capture(ctx context.Context) error {
+	cpuErr := p.captureCPU(ctx)
+	heapErr := p.captureHeap()
+	return errors.Join(cpuErr, heapErr,
+		p.prune(KindCPU),
+		p.prune(KindHeap),
+	)
+}
+
+// captureCPU записывает CPU-профиль.
+func (p *Profiler) captureCPU(ctx context.Context) error {
+	return p.write(KindCPU, func(f *os.File) error {
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		timer := time.NewTimer(p.opts.CPUDuration)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		pprof.StopCPUProfile()
+		return nil
+	})
+}
+
+// captureHeap записывает профиль кучи после сборки мусора, чтобы
+// в нём были актуальные данные о живых объектах.
+func (p *Profiler) captureHeap() error {
+	return p.write(KindHeap, func(f *os.File) error {
+		runtime.GC()
+		return pprof.Lookup("heap").WriteTo(f, 0)
+	})
+}
+
+// write создаёт файл профиля вида kind и заполняет его функцией fill.
+// Профиль пишется во временный файл и переименовывается по готовности,
+// чтобы в каталоге не оставалось обрезанных профилей.
+func (p *Profiler) write(kind string, fill func(*os.File) error) error {
+	name := filepath.Join(p.opts.Dir, fmt.Sprintf("%s-%s.pprof", kind, p.now().UTC().Format(timeFormat)))
+	f, err := os.CreateTemp(p.opts.Dir, "."+kind+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("%s profile: %w", kind, err)
+	}
+	defer os.Remove(f.Name())
+	if err := fill(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s profile: %w", kind, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("%s profile: %w", kind, err)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("%s profile: %w", kind, err)
+	}
+	return nil
+}
+
+// prune удаляет самые старые профили вида kind, оставляя Keep последних.
+func (p *Profiler) prune(kind string) error {
+	profiles, err := List(p.opts.Dir, kind)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for len(profiles) > p.opts.Keep {
+		if err := os.Remove(profiles[0]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		profiles = profiles[1:]
+	}
+	return errors.Join(errs...)
+}
+
+// List возвращает пути профилей вида kind в каталоге dir от старых к новым.
+func List(dir, kind string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, kind+"-") && strings.HasSuffix(name, ".pprof") {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
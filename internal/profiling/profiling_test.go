@@ -0,0 +1,40 @@
+package profiling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	p, err := New(Options{Dir: dir, Interval: time.Minute, CPUDuration: 10 * time.Millisecond, Keep: 2})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Capture(context.Background()))
+		now = now.Add(time.Minute)
+	}
+
+	for _, kind := range []string{KindCPU, KindHeap} {
+		profiles, err := List(dir, kind)
+		require.NoError(t, err)
+		require.Len(t, profiles, 2, kind)
+		assert.Equal(t, kind+"-20260102T150505Z.pprof", filepath.Base(profiles[0]))
+		assert.Equal(t, kind+"-20260102T150605Z.pprof", filepath.Base(profiles[1]))
+		info, err := os.Stat(profiles[1])
+		require.NoError(t, err)
+		assert.Positive(t, info.Size())
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4, "temporary files are removed")
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// Limiter применяет лимиты групп маршрутов, храня корзины в Store.
+// Лимиты можно заменить на лету через SetLimits.
+type Limiter struct {
+	store   Store
+	trusted []netip.Prefix
+
+	mu     sync.RWMutex
+	limits map[string]Limit
+}
+
+// NewLimiter создаёт ограничитель с лимитами групп limits. trustedProxies —
+// адреса и подсети обратных прокси, которым разрешено передавать адрес
+// клиента в X-Forwarded-For и X-Real-IP.
+func NewLimiter(store Store, limits map[string]Limit, trustedProxies []string) (*Limiter, error) {
+	trusted, err := ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	l := &Limiter{store: store, trusted: trusted}
+	l.SetLimits(limits)
+	return l, nil
+}
+
+// SetLimits заменяет лимиты групп. Группы без лимита не ограничиваются.
+func (l *Limiter) SetLimits(limits map[string]Limit) {
+	copied := make(map[string]Limit, len(limits))
+	for group, limit := range limits {
+		copied[group] = limit
+	}
+	l.mu.Lock()
+	l.limits = copied
+	l.mu.Unlock()
+}
+
+// Limit возвращает лимит группы.
+func (l *Limiter) Limit(group string) Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limits[group]
+}
+
+// Take списывает токен группы group для ключа key (пользователя или адреса).
+func (l *Limiter) Take(ctx context.Context, group string, key string) (Result, error) {
+	return l.store.Take(ctx, group+":"+key, l.Limit(group))
+}
+
+// ClientIP возвращает адрес клиента. Заголовки X-Forwarded-For и X-Real-IP
+// учитываются, только если запрос пришёл от доверенного прокси; из цепочки
+// X-Forwarded-For берётся самый правый недоверенный адрес.
+func (l *Limiter) ClientIP(r *http.Request) string {
+	remote := remoteAddr(r)
+	if !l.isTrusted(remote) {
+		return remote.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			if !l.isTrusted(addr) {
+				return addr.Unmap().String()
+			}
+		}
+	}
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return remote.String()
+}
+
+func (l *Limiter) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range l.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr извлекает адрес из RemoteAddr запроса.
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// ParseTrustedProxies разбирает список адресов и подсетей CIDR доверенных
+// прокси. Пустые элементы пропускаются.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,162 @@
+// Package ratelimit реализует ограничение частоты запросов по алгоритму
+// token bucket с хранением состояния в памяти или во внешнем хранилище.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Группы маршрутов с независимыми лимитами.
+const (
+	GroupShorten  = "shorten"
+	GroupBatch    = "batch"
+	GroupRedirect = "redirect"
+	GroupAPI      = "api"
+	GroupAuth     = "auth"
+)
+
+// Limit — параметры корзины токенов: Rate токенов в секунду при ёмкости Burst.
+// Нулевой Limit означает отсутствие ограничения.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited сообщает, что лимит не ограничивает запросы.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// ParseLimit разбирает лимит вида "N/s", "N/m" или "N/h": не более N запросов
+// за период с возможностью потратить их все сразу. Пустая строка и "0"
+// означают отсутствие ограничения.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+	count, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected N/period", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be s, m or h", s)
+	}
+	if n == 0 {
+		return Limit{}, nil
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}, nil
+}
+
+// Result — итог попытки взять токен.
+type Result struct {
+	// Allowed — запрос укладывается в лимит.
+	Allowed bool
+	// Limit — ёмкость корзины.
+	Limit int
+	// Remaining — сколько запросов ещё можно сделать немедленно.
+	Remaining int
+	// Reset — через сколько корзина наполнится полностью.
+	Reset time.Duration
+	// RetryAfter — через сколько появится следующий токен, если запрос отклонён.
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние корзин. Реализация во внешнем хранилище (например,
+// Redis) позволяет нескольким экземплярам сервиса делить общие лимиты.
+type Store interface {
+	// Take списывает один токен из корзины key с параметрами limit.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket — состояние одной корзины.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// sweepInterval — как часто MemoryStore удаляет наполнившиеся корзины.
+const sweepInterval = time.Minute
+
+// MemoryStore — хранилище корзин в памяти процесса.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore создаёт пустое хранилище корзин в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take списывает токен из корзины key, предварительно пополнив её за
+// прошедшее время.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	b.limit = limit
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return res, nil
+}
+
+// sweep удаляет корзины, которые успели наполниться: они неотличимы от новых.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// seconds переводит дробное число секунд в time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("120/m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 2, Burst: 120}, limit)
+
+	for _, value := range []string{"", "0", "0/s"} {
+		limit, err := ParseLimit(value)
+		require.NoError(t, err, value)
+		assert.True(t, limit.Unlimited(), value)
+	}
+	for _, value := range []string{"10", "x/s", "-1/s", "10/d"} {
+		_, err := ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for want := 1; want >= 0; want-- {
+		res, err := store.Take(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, want, res.Remaining)
+	}
+
+	res, err := store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 2*time.Second, res.Reset)
+
+	// другие ключи не затрагиваются
+	res, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(time.Second)
+	res, err = store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// наполнившиеся корзины удаляются при очистке
+	now = now.Add(sweepInterval)
+	_, err = store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestClientIP(t *testing.T) {
+	limiter, err := NewLimiter(NewMemoryStore(), nil, []string{"10.0.0.0/8", "::1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		remote string
+		header map[string]string
+		want   string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted forwarded", "203.0.113.5:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.5"},
+		{"trusted forwarded", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9, 10.0.0.2"}, "203.0.113.9"},
+		{"trusted real ip", "[::1]:1234", map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		{"trusted without headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, limiter.ClientIP(r))
+		})
+	}
+
+	_, err = NewLimiter(NewMemoryStore(), nil, []string{"not-an-ip"})
+	assert.Error(t, err)
+}
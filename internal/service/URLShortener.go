@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tracing"
 	"github.com/AlexeySalamakhin/URLShortener/internal/utils"
 )
 
@@ -14,37 +18,137 @@ import (
 type Store interface {
 	Save(ctx context.Context, originalURL string, shortURL string, userID string) error
 	GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool)
-	GetShortURL(ctx context.Context, originalURL string) (string, error)
+	GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error)
 	Ready() bool
 	GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error)
 	DeleteUserURLs(ctx context.Context, userID string, ids []string) error
+	SaveAPIKey(ctx context.Context, key models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error
+	CreateUser(ctx context.Context, user models.User) error
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	SaveSession(ctx context.Context, session models.Session) error
+	GetSession(ctx context.Context, hash string) (models.Session, error)
+	DeleteSession(ctx context.Context, hash string) error
+	TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error)
+	SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error)
+	GetURL(ctx context.Context, shortURL string) (models.URLRecord, error)
+	SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error
+	SetURLOwner(ctx context.Context, shortURL string, userID string) error
+	SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error
+	GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error)
+	GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error)
+	UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error
+	DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error
+	CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error
+	GetTeam(ctx context.Context, teamID string) (models.Team, error)
+	GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error)
+	GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error)
+	GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error)
+	SaveTeamMember(ctx context.Context, member models.TeamMember) error
+	DeleteTeamMember(ctx context.Context, teamID string, userID string) error
+	SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error
+	GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error)
+	DeleteTeamInvite(ctx context.Context, hash string) error
 }
 
 // URLShortener реализует бизнес-логику сокращения ссылок.
 type URLShortener struct {
-	store Store
+	store      Store
+	scope      models.DedupScope
+	sessionTTL time.Duration
+}
+
+// Option настраивает экземпляр URLShortener.
+type Option func(*URLShortener)
+
+// WithDedupScope задаёт область дедупликации сокращаемых ссылок.
+func WithDedupScope(scope models.DedupScope) Option {
+	return func(u *URLShortener) {
+		u.scope = scope
+	}
 }
 
 // NewURLShortener создаёт новый экземпляр сервиса с переданным хранилищем.
-func NewURLShortener(store Store) *URLShortener {
-	return &URLShortener{store: store}
+// По умолчанию используется глобальная дедупликация ссылок.
+func NewURLShortener(store Store, opts ...Option) *URLShortener {
+	u := &URLShortener{store: store, scope: models.DedupGlobal, sessionTTL: defaultSessionTTL}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
-// Shorten сокращает исходный URL и возвращает короткий ключ.
-// Второй параметр возвращаемого значения равен true, если ссылка уже существовала.
-func (u *URLShortener) Shorten(ctx context.Context, originalURL string, userID string) (string, bool) {
-	foundURL, err := u.store.GetShortURL(ctx, originalURL)
-	if err != nil && errors.Is(err, store.ErrShortURLNotFound) {
-		shortKey := utils.GenerateShortURL()
-		u.store.Save(ctx, originalURL, shortKey, userID)
-		return shortKey, false
+// Shorten сокращает исходный URL и возвращает результат сокращения.
+// Если ссылка уже существует в пределах области дедупликации, в результате
+// выставляется флаг Conflict, а Owned показывает, принадлежит ли она userID.
+func (u *URLShortener) Shorten(ctx context.Context, originalURL string, userID string) (res models.ShortenResult, err error) {
+	ctx, span := tracing.Start(ctx, "URLShortener.Shorten", attribute.String("dedup.scope", string(u.scope)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("shorten.conflict", res.Conflict))
+		tracing.End(span, err)
+	}()
+
+	if u.scope != models.DedupNone {
+		res, found, err := u.findExisting(ctx, originalURL, userID)
+		if err != nil || found {
+			return res, err
+		}
 	}
-	return foundURL, true
+
+	shortKey := utils.GenerateShortURL()
+	err = u.store.Save(ctx, originalURL, shortKey, userID)
+	if errors.Is(err, store.ErrOriginalURLExists) {
+		// ссылку успели сохранить параллельным запросом
+		var existing *store.ExistingURLError
+		if errors.As(err, &existing) {
+			return models.ShortenResult{
+				ShortURL: existing.Record.ShortURL,
+				Conflict: true,
+				Owned:    existing.Record.UserID == userID,
+			}, nil
+		}
+		res, found, err := u.findExisting(ctx, originalURL, userID)
+		if err == nil && !found {
+			err = store.ErrOriginalURLExists
+		}
+		return res, err
+	}
+	if err != nil {
+		return models.ShortenResult{}, err
+	}
+	return models.ShortenResult{ShortURL: shortKey, Owned: true}, nil
+}
+
+// findExisting ищет уже сокращённую ссылку с учётом области дедупликации.
+func (u *URLShortener) findExisting(ctx context.Context, originalURL string, userID string) (models.ShortenResult, bool, error) {
+	lookupUserID := ""
+	if u.scope == models.DedupUser {
+		lookupUserID = userID
+	}
+
+	record, err := u.store.GetShortURL(ctx, originalURL, lookupUserID)
+	if errors.Is(err, store.ErrShortURLNotFound) {
+		return models.ShortenResult{}, false, nil
+	}
+	if err != nil {
+		return models.ShortenResult{}, false, err
+	}
+	return models.ShortenResult{
+		ShortURL: record.ShortURL,
+		Conflict: true,
+		Owned:    record.UserID == userID,
+	}, true, nil
 }
 
 // GetOriginalURL возвращает исходный URL по короткому ключу.
 func (u *URLShortener) GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool) {
+	ctx, span := tracing.Start(ctx, "URLShortener.GetOriginalURL")
+	defer span.End()
+
 	record, found := u.store.GetOriginalURL(ctx, shortURL)
+	span.SetAttributes(attribute.Bool("url.found", found))
 	return record, found
 }
 
@@ -54,7 +158,10 @@ func (u *URLShortener) StoreReady() bool {
 }
 
 // GetUserURLs возвращает список ссылок пользователя.
-func (u *URLShortener) GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error) {
+func (u *URLShortener) GetUserURLs(ctx context.Context, userID string) (urls []models.UserURLsResponse, err error) {
+	ctx, span := tracing.Start(ctx, "URLShortener.GetUserURLs")
+	defer func() { tracing.End(span, err) }()
+
 	return u.store.GetUserURLs(ctx, userID)
 }
 
@@ -90,7 +197,10 @@ func fanIn(doneCh chan struct{}, resultChs ...chan error) chan error {
 }
 
 // DeleteUserURLs удаляет (помечает удалёнными) ссылки пользователя батчами и конкурентно.
-func (u *URLShortener) DeleteUserURLs(ctx context.Context, userID string, ids []string) error {
+func (u *URLShortener) DeleteUserURLs(ctx context.Context, userID string, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "URLShortener.DeleteUserURLs", attribute.Int("urls.count", len(ids)))
+	defer func() { tracing.End(span, err) }()
+
 	if len(ids) == 0 {
 		return nil
 	}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+)
+
+// Ограничения на длину пароля; bcrypt учитывает только первые 72 байта.
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 72
+)
+
+// defaultSessionTTL — время жизни сессии по умолчанию.
+const defaultSessionTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidEmail возвращается, если email имеет неверный формат.
+	ErrInvalidEmail = errors.New("invalid email")
+	// ErrInvalidPassword возвращается, если пароль не удовлетворяет требованиям к длине.
+	ErrInvalidPassword = fmt.Errorf("password must be %d to %d bytes long", minPasswordLen, maxPasswordLen)
+	// ErrEmailTaken возвращается при регистрации уже занятого email.
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrInvalidLogin возвращается при неверной паре email/пароль.
+	ErrInvalidLogin = errors.New("invalid email or password")
+)
+
+// dummyHash — хэш, с которым сравнивается пароль при входе несуществующего
+// пользователя, чтобы время ответа не выдавало наличие учётной записи.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// WithSessionTTL задаёт время жизни сессий зарегистрированных пользователей.
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(u *URLShortener) {
+		u.sessionTTL = ttl
+	}
+}
+
+// Register создаёт учётную запись с email и паролем.
+func (u *URLShortener) Register(ctx context.Context, email string, password string) (models.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return models.User{}, err
+	}
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return models.User{}, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	user := models.User{
+		ID:           uuid.New().String(),
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	err = u.store.CreateUser(ctx, user)
+	if errors.Is(err, store.ErrUserExists) {
+		return models.User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// Login проверяет email и пароль и возвращает учётную запись.
+func (u *URLShortener) Login(ctx context.Context, email string, password string) (models.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return models.User{}, ErrInvalidLogin
+	}
+
+	user, err := u.store.GetUserByEmail(ctx, email)
+	if errors.Is(err, store.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return models.User{}, ErrInvalidLogin
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return models.User{}, ErrInvalidLogin
+	}
+	return user, nil
+}
+
+// CreateSession открывает сессию пользователя и возвращает её токен и время истечения.
+func (u *URLShortener) CreateSession(ctx context.Context, userID string) (string, time.Time, error) {
+	token := auth.GenerateSessionToken()
+	now := time.Now().UTC()
+	session := models.Session{
+		Hash:      auth.HashSessionToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.sessionTTL),
+	}
+	if err := u.store.SaveSession(ctx, session); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, session.ExpiresAt, nil
+}
+
+// Logout завершает сессию с указанным токеном.
+func (u *URLShortener) Logout(ctx context.Context, token string) error {
+	return u.store.DeleteSession(ctx, auth.HashSessionToken(token))
+}
+
+// AuthenticateSession возвращает идентификатор пользователя сессии.
+// Реализует auth.SessionLookup.
+func (u *URLShortener) AuthenticateSession(ctx context.Context, token string) (string, error) {
+	session, err := u.store.GetSession(ctx, auth.HashSessionToken(token))
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return "", fmt.Errorf("%w: unknown session", auth.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return "", err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return "", fmt.Errorf("%w: session expired", auth.ErrInvalidCredentials)
+	}
+	return session.UserID, nil
+}
+
+// ClaimURLs переносит ссылки анонимного пользователя в учётную запись
+// и возвращает число перенесённых ссылок.
+func (u *URLShortener) ClaimURLs(ctx context.Context, anonymousID string, userID string) (int, error) {
+	if anonymousID == "" || anonymousID == userID {
+		return 0, nil
+	}
+	return u.store.TransferUserURLs(ctx, anonymousID, userID)
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+)
+
+// Ограничения размера страницы поиска ссылок.
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+var (
+	// ErrURLNotFound возвращается, если ссылки с таким коротким ключом нет.
+	ErrURLNotFound = errors.New("short URL not found")
+	// ErrOwnerHasURL возвращается при смене владельца, если у нового владельца
+	// уже есть ссылка на тот же URL, а дедупликация действует в пределах пользователя.
+	ErrOwnerHasURL = errors.New("new owner already has a link to this URL")
+)
+
+// SearchURLs ищет ссылки всех пользователей. Размер страницы по умолчанию
+// равен 100 и не может превышать 1000.
+func (u *URLShortener) SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	filter.Limit = min(filter.Limit, maxSearchLimit)
+	filter.Offset = max(filter.Offset, 0)
+	return u.store.SearchURLs(ctx, filter)
+}
+
+// GetURL возвращает ссылку по короткому ключу вместе с владельцем.
+func (u *URLShortener) GetURL(ctx context.Context, shortURL string) (models.URLRecord, error) {
+	record, err := u.store.GetURL(ctx, shortURL)
+	if errors.Is(err, store.ErrShortURLNotFound) {
+		return models.URLRecord{}, ErrURLNotFound
+	}
+	return record, err
+}
+
+// UpdateURL отключает или включает ссылку и меняет её владельца.
+// Возвращает ссылку в новом состоянии.
+func (u *URLShortener) UpdateURL(ctx context.Context, shortURL string, update models.AdminURLUpdate) (models.URLRecord, error) {
+	record, err := u.GetURL(ctx, shortURL)
+	if err != nil {
+		return models.URLRecord{}, err
+	}
+
+	if update.UserID != nil && *update.UserID != record.UserID {
+		if err := u.setOwner(ctx, record, *update.UserID); err != nil {
+			return models.URLRecord{}, err
+		}
+	}
+	if update.Disabled != nil && *update.Disabled != record.Disabled {
+		if err := u.store.SetURLDisabled(ctx, shortURL, *update.Disabled); err != nil {
+			return models.URLRecord{}, err
+		}
+	}
+	return u.GetURL(ctx, shortURL)
+}
+
+func (u *URLShortener) setOwner(ctx context.Context, record models.URLRecord, userID string) error {
+	// у ссылок рабочих пространств меняется только автор, дедупликация от него не зависит
+	if u.scope == models.DedupUser && !record.DeletedFlag && record.TeamID == "" {
+		_, err := u.store.GetShortURL(ctx, record.OriginalURL, userID)
+		if err == nil {
+			return ErrOwnerHasURL
+		}
+		if !errors.Is(err, store.ErrShortURLNotFound) {
+			return err
+		}
+	}
+
+	err := u.store.SetURLOwner(ctx, record.ShortURL, userID)
+	switch {
+	case errors.Is(err, store.ErrOriginalURLExists):
+		return ErrOwnerHasURL
+	case errors.Is(err, store.ErrShortURLNotFound):
+		return ErrURLNotFound
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+)
+
+// CreateAPIKey выпускает пользователю новый API-ключ. Сам ключ возвращается
+// только здесь, в хранилище сохраняется лишь его хэш.
+func (u *URLShortener) CreateAPIKey(ctx context.Context, userID string, name string) (models.APIKeyResponse, error) {
+	key, prefix := auth.GenerateAPIKey()
+	record := models.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      auth.HashAPIKey(key),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := u.store.SaveAPIKey(ctx, record); err != nil {
+		return models.APIKeyResponse{}, err
+	}
+
+	resp := apiKeyResponse(record)
+	resp.Key = key
+	return resp, nil
+}
+
+// GetAPIKeys возвращает API-ключи пользователя без самих ключей.
+func (u *URLShortener) GetAPIKeys(ctx context.Context, userID string) ([]models.APIKeyResponse, error) {
+	keys, err := u.store.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, apiKeyResponse(key))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKeys отзывает API-ключи пользователя с указанными идентификаторами.
+func (u *URLShortener) RevokeAPIKeys(ctx context.Context, userID string, ids []string) error {
+	return u.store.DeleteUserAPIKeys(ctx, userID, ids)
+}
+
+// AuthenticateAPIKey возвращает идентификатор владельца API-ключа.
+// Реализует auth.APIKeyLookup.
+func (u *URLShortener) AuthenticateAPIKey(ctx context.Context, key string) (string, error) {
+	record, err := u.store.GetAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	if errors.Is(err, store.ErrAPIKeyNotFound) {
+		return "", fmt.Errorf("%w: unknown API key", auth.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return "", err
+	}
+	return record.UserID, nil
+}
+
+func apiKeyResponse(key models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+	}
+}
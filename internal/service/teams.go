@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/auth"
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+	"github.com/AlexeySalamakhin/URLShortener/internal/store"
+	"github.com/AlexeySalamakhin/URLShortener/internal/tracing"
+	"github.com/AlexeySalamakhin/URLShortener/internal/utils"
+)
+
+// maxTeamNameLen — максимальная длина названия рабочего пространства.
+const maxTeamNameLen = 100
+
+// teamInviteTTL — время действия приглашения в рабочее пространство.
+const teamInviteTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidTeamName возвращается, если название пустое или слишком длинное.
+	ErrInvalidTeamName = errors.New("team name must be 1 to 100 characters long")
+	// ErrTeamNotFound возвращается, если рабочего пространства нет или
+	// пользователь в нём не состоит.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamForbidden возвращается, если роли пользователя недостаточно для операции.
+	ErrTeamForbidden = errors.New("insufficient team role")
+	// ErrInvalidInvite возвращается для неизвестного или истёкшего приглашения.
+	ErrInvalidInvite = errors.New("invalid or expired invite")
+	// ErrURLExists возвращается при редактировании, если новый URL уже сокращён
+	// в пределах области дедупликации.
+	ErrURLExists = errors.New("URL is already shortened")
+	// ErrLastTeamAdmin возвращается при попытке исключить последнего администратора.
+	ErrLastTeamAdmin = errors.New("team must keep at least one admin")
+)
+
+// CreateTeam создаёт рабочее пространство; создатель становится его администратором.
+func (u *URLShortener) CreateTeam(ctx context.Context, userID string, name string) (models.TeamResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxTeamNameLen {
+		return models.TeamResponse{}, ErrInvalidTeamName
+	}
+
+	now := time.Now().UTC()
+	team := models.Team{ID: uuid.New().String(), Name: name, CreatedAt: now}
+	owner := models.TeamMember{TeamID: team.ID, UserID: userID, Role: models.TeamRoleAdmin, JoinedAt: now}
+	if err := u.store.CreateTeam(ctx, team, owner); err != nil {
+		return models.TeamResponse{}, err
+	}
+	return models.TeamResponse{ID: team.ID, Name: team.Name, Role: owner.Role}, nil
+}
+
+// GetTeams возвращает рабочие пространства, в которых состоит пользователь.
+func (u *URLShortener) GetTeams(ctx context.Context, userID string) ([]models.TeamResponse, error) {
+	return u.store.GetUserTeams(ctx, userID)
+}
+
+// GetTeamMembers возвращает участников рабочего пространства. Доступно любому участнику.
+func (u *URLShortener) GetTeamMembers(ctx context.Context, userID string, teamID string) ([]models.TeamMember, error) {
+	if _, err := u.teamRole(ctx, teamID, userID); err != nil {
+		return nil, err
+	}
+	members, err := u.store.GetTeamMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(members, func(a, b models.TeamMember) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+	return members, nil
+}
+
+// CreateTeamInvite выпускает одноразовое приглашение с ролью role.
+// Доступно только администраторам пространства.
+func (u *URLShortener) CreateTeamInvite(ctx context.Context, userID string, teamID string, role models.TeamRole) (models.TeamInviteResponse, error) {
+	if err := u.requireTeamAdmin(ctx, teamID, userID); err != nil {
+		return models.TeamInviteResponse{}, err
+	}
+
+	token := auth.GenerateSessionToken()
+	invite := models.TeamInvite{
+		Hash:      auth.HashSessionToken(token),
+		TeamID:    teamID,
+		Role:      role,
+		CreatedBy: userID,
+		ExpiresAt: time.Now().Add(teamInviteTTL).UTC(),
+	}
+	if err := u.store.SaveTeamInvite(ctx, invite); err != nil {
+		return models.TeamInviteResponse{}, err
+	}
+	return models.TeamInviteResponse{Token: token, Role: role, ExpiresAt: invite.ExpiresAt}, nil
+}
+
+// JoinTeam принимает приглашение. Приглашение одноразовое; роль уже
+// состоящего в пространстве пользователя может только повыситься.
+func (u *URLShortener) JoinTeam(ctx context.Context, userID string, token string) (models.TeamResponse, error) {
+	hash := auth.HashSessionToken(token)
+	invite, err := u.store.GetTeamInvite(ctx, hash)
+	if errors.Is(err, store.ErrTeamInviteNotFound) {
+		return models.TeamResponse{}, ErrInvalidInvite
+	}
+	if err != nil {
+		return models.TeamResponse{}, err
+	}
+	if err := u.store.DeleteTeamInvite(ctx, hash); err != nil {
+		return models.TeamResponse{}, err
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return models.TeamResponse{}, ErrInvalidInvite
+	}
+
+	team, err := u.store.GetTeam(ctx, invite.TeamID)
+	if errors.Is(err, store.ErrTeamNotFound) {
+		return models.TeamResponse{}, ErrInvalidInvite
+	}
+	if err != nil {
+		return models.TeamResponse{}, err
+	}
+
+	member := models.TeamMember{TeamID: team.ID, UserID: userID, Role: invite.Role, JoinedAt: time.Now().UTC()}
+	existing, err := u.store.GetTeamMember(ctx, team.ID, userID)
+	switch {
+	case err == nil && existing.Role == models.TeamRoleAdmin:
+		return models.TeamResponse{ID: team.ID, Name: team.Name, Role: existing.Role}, nil
+	case err == nil:
+		member.JoinedAt = existing.JoinedAt
+	case !errors.Is(err, store.ErrTeamMemberNotFound):
+		return models.TeamResponse{}, err
+	}
+	if err := u.store.SaveTeamMember(ctx, member); err != nil {
+		return models.TeamResponse{}, err
+	}
+	return models.TeamResponse{ID: team.ID, Name: team.Name, Role: member.Role}, nil
+}
+
+// RemoveTeamMember исключает участника memberID. Администратор может исключить
+// любого участника, остальные — только выйти сами. Последнего администратора
+// исключить нельзя.
+func (u *URLShortener) RemoveTeamMember(ctx context.Context, userID string, teamID string, memberID string) error {
+	role, err := u.teamRole(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && role != models.TeamRoleAdmin {
+		return ErrTeamForbidden
+	}
+
+	members, err := u.store.GetTeamMembers(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	admins := 0
+	var target *models.TeamMember
+	for i, m := range members {
+		if m.Role == models.TeamRoleAdmin {
+			admins++
+		}
+		if m.UserID == memberID {
+			target = &members[i]
+		}
+	}
+	if target == nil {
+		return ErrTeamNotFound
+	}
+	if target.Role == models.TeamRoleAdmin && admins == 1 {
+		return ErrLastTeamAdmin
+	}
+	return u.store.DeleteTeamMember(ctx, teamID, memberID)
+}
+
+// ShortenInTeam сокращает URL в рабочее пространство. При дедупликации
+// в пределах пользователя повторно используются ссылки этого пространства.
+func (u *URLShortener) ShortenInTeam(ctx context.Context, userID string, teamID string, originalURL string) (res models.ShortenResult, err error) {
+	ctx, span := tracing.Start(ctx, "URLShortener.ShortenInTeam", attribute.String("team.id", teamID))
+	defer func() {
+		span.SetAttributes(attribute.Bool("shorten.conflict", res.Conflict))
+		tracing.End(span, err)
+	}()
+
+	if _, err := u.teamRole(ctx, teamID, userID); err != nil {
+		return models.ShortenResult{}, err
+	}
+
+	find := func() (models.ShortenResult, bool, error) {
+		var record models.URLRecord
+		var err error
+		switch u.scope {
+		case models.DedupNone:
+			return models.ShortenResult{}, false, nil
+		case models.DedupUser:
+			record, err = u.store.GetTeamShortURL(ctx, teamID, originalURL)
+		default:
+			record, err = u.store.GetShortURL(ctx, originalURL, "")
+		}
+		if errors.Is(err, store.ErrShortURLNotFound) {
+			return models.ShortenResult{}, false, nil
+		}
+		if err != nil {
+			return models.ShortenResult{}, false, err
+		}
+		return models.ShortenResult{ShortURL: record.ShortURL, Conflict: true, Owned: record.TeamID == teamID}, true, nil
+	}
+
+	res, found, err := find()
+	if err != nil || found {
+		return res, err
+	}
+
+	shortKey := utils.GenerateShortURL()
+	err = u.store.SaveTeamURL(ctx, teamID, originalURL, shortKey, userID)
+	if errors.Is(err, store.ErrOriginalURLExists) {
+		// ссылку успели сохранить параллельным запросом
+		var existing *store.ExistingURLError
+		if errors.As(err, &existing) {
+			return models.ShortenResult{ShortURL: existing.Record.ShortURL, Conflict: true, Owned: existing.Record.TeamID == teamID}, nil
+		}
+		res, found, err := find()
+		if err == nil && !found {
+			err = store.ErrOriginalURLExists
+		}
+		return res, err
+	}
+	if err != nil {
+		return models.ShortenResult{}, err
+	}
+	return models.ShortenResult{ShortURL: shortKey, Owned: true}, nil
+}
+
+// GetTeamURLs возвращает ссылки рабочего пространства. Доступно любому участнику.
+func (u *URLShortener) GetTeamURLs(ctx context.Context, userID string, teamID string) ([]models.URLRecord, error) {
+	if _, err := u.teamRole(ctx, teamID, userID); err != nil {
+		return nil, err
+	}
+	return u.store.GetTeamURLs(ctx, teamID)
+}
+
+// EditTeamURL меняет исходный URL ссылки рабочего пространства.
+// Участник может менять только свои ссылки, администратор — любые.
+func (u *URLShortener) EditTeamURL(ctx context.Context, userID string, teamID string, shortURL string, originalURL string) error {
+	role, err := u.teamRole(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	record, err := u.store.GetURL(ctx, shortURL)
+	if errors.Is(err, store.ErrShortURLNotFound) || err == nil && (record.TeamID != teamID || record.DeletedFlag) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role != models.TeamRoleAdmin && record.UserID != userID {
+		return ErrTeamForbidden
+	}
+
+	var existing models.URLRecord
+	switch u.scope {
+	case models.DedupUser:
+		existing, err = u.store.GetTeamShortURL(ctx, teamID, originalURL)
+	case models.DedupGlobal:
+		existing, err = u.store.GetShortURL(ctx, originalURL, "")
+	default:
+		err = store.ErrShortURLNotFound
+	}
+	if err == nil && existing.ShortURL != shortURL {
+		return ErrURLExists
+	}
+	if err != nil && !errors.Is(err, store.ErrShortURLNotFound) {
+		return err
+	}
+
+	err = u.store.UpdateTeamURL(ctx, teamID, shortURL, originalURL)
+	switch {
+	case errors.Is(err, store.ErrShortURLNotFound):
+		return ErrURLNotFound
+	case errors.Is(err, store.ErrOriginalURLExists):
+		return ErrURLExists
+	}
+	return err
+}
+
+// DeleteTeamURLs помечает удалёнными ссылки рабочего пространства.
+// Ссылки, которые пользователь удалять не вправе, пропускаются:
+// участник удаляет только свои ссылки, администратор — любые.
+func (u *URLShortener) DeleteTeamURLs(ctx context.Context, userID string, teamID string, ids []string) error {
+	role, err := u.teamRole(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.TeamRoleAdmin {
+		urls, err := u.store.GetTeamURLs(ctx, teamID)
+		if err != nil {
+			return err
+		}
+		own := make(map[string]bool)
+		for _, record := range urls {
+			if record.UserID == userID {
+				own[record.ShortURL] = true
+			}
+		}
+		ids = slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return !own[id] })
+	}
+	return u.store.DeleteTeamURLs(ctx, teamID, ids)
+}
+
+// teamRole возвращает роль пользователя в рабочем пространстве или
+// ErrTeamNotFound, если пользователь в нём не состоит.
+func (u *URLShortener) teamRole(ctx context.Context, teamID string, userID string) (models.TeamRole, error) {
+	member, err := u.store.GetTeamMember(ctx, teamID, userID)
+	if errors.Is(err, store.ErrTeamMemberNotFound) {
+		return "", ErrTeamNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+func (u *URLShortener) requireTeamAdmin(ctx context.Context, teamID string, userID string) error {
+	role, err := u.teamRole(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.TeamRoleAdmin {
+		return ErrTeamForbidden
+	}
+	return nil
+}
@@ -4,24 +4,113 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 )
 
+// uniqueViolationCode — код ошибки PostgreSQL при нарушении ограничения уникальности.
+const uniqueViolationCode = "23505"
+
+// foreignKeyViolationCode — код ошибки PostgreSQL при нарушении внешнего ключа.
+const foreignKeyViolationCode = "23503"
+
+// Имена уникальных индексов по исходному URL для разных областей дедупликации.
+const (
+	globalDedupIndex = "urls_original_url_global_uniq"
+	userDedupIndex   = "urls_original_url_owner_uniq"
+	teamDedupIndex   = "urls_original_url_team_uniq"
+	// legacyUserDedupIndex — индекс области "user" до появления рабочих пространств.
+	legacyUserDedupIndex = "urls_original_url_user_uniq"
+)
+
+// migrations — упорядоченный список миграций схемы; номер версии равен индексу + 1.
+var migrations = []string{
+	// 1: исходная схема таблицы ссылок.
+	`CREATE TABLE IF NOT EXISTS urls (
+		uuid SERIAL PRIMARY KEY,
+		short_url VARCHAR(255) UNIQUE NOT NULL,
+		original_url TEXT UNIQUE NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		is_deleted BOOLEAN DEFAULT FALSE
+	)`,
+	// 2: глобальное ограничение уникальности original_url заменяется
+	// индексом, который зависит от области дедупликации (см. ensureDedupIndex).
+	`ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_original_url_key`,
+	// 3: персональные API-ключи пользователей.
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(64) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		prefix VARCHAR(32) NOT NULL,
+		key_hash CHAR(64) UNIQUE NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id)`,
+	// 4: учётные записи и сессии.
+	`CREATE TABLE IF NOT EXISTS users (
+		id VARCHAR(255) PRIMARY KEY,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash CHAR(64) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+	// 5: сессии пользователей, вошедших через OpenID Connect, не имеют записи в users.
+	`ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_user_id_fkey`,
+	// 6: отключение ссылок администратором.
+	`ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_disabled BOOLEAN NOT NULL DEFAULT FALSE`,
+	// 7: рабочие пространства, их участники, приглашения и ссылки.
+	`CREATE TABLE IF NOT EXISTS teams (
+		id VARCHAR(36) PRIMARY KEY,
+		name TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE IF NOT EXISTS team_members (
+		team_id VARCHAR(36) NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+		user_id VARCHAR(255) NOT NULL,
+		role VARCHAR(16) NOT NULL,
+		joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (team_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS team_members_user_id_idx ON team_members (user_id);
+	CREATE TABLE IF NOT EXISTS team_invites (
+		token_hash CHAR(64) PRIMARY KEY,
+		team_id VARCHAR(36) NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+		role VARCHAR(16) NOT NULL,
+		created_by VARCHAR(255) NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+	ALTER TABLE urls ADD COLUMN IF NOT EXISTS team_id VARCHAR(36) NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS urls_team_id_idx ON urls (team_id) WHERE team_id <> ''`,
+}
+
 // PostgresStore реализует хранилище ссылок на базе PostgreSQL.
 type PostgresStore struct {
-	pool *pgxpool.Pool
+	pool  *pgxpool.Pool
+	scope models.DedupScope
 }
 
-// NewDBStore инициализирует подключение к БД и возвращает экземпляр PostgresStore.
-func NewDBStore(connStr string) (*PostgresStore, error) {
+// NewDBStore инициализирует подключение к БД, применяет миграции и возвращает
+// экземпляр PostgresStore. Ограничение уникальности исходного URL
+// настраивается в соответствии с областью дедупликации scope.
+func NewDBStore(connStr string, scope models.DedupScope) (*PostgresStore, error) {
 	config, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %v", err)
 	}
+	config.ConnConfig.Tracer = queryTracer{}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
@@ -29,7 +118,8 @@ func NewDBStore(connStr string) (*PostgresStore, error) {
 	}
 
 	store := &PostgresStore{
-		pool: pool,
+		pool:  pool,
+		scope: scope,
 	}
 
 	if err := store.initDB(); err != nil {
@@ -41,43 +131,149 @@ func NewDBStore(connStr string) (*PostgresStore, error) {
 }
 
 func (s *PostgresStore) initDB() error {
-	_, err := s.pool.Exec(context.Background(), `
-		CREATE TABLE IF NOT EXISTS urls (
-			uuid SERIAL PRIMARY KEY,
-			short_url VARCHAR(255) UNIQUE NOT NULL,
-			original_url TEXT UNIQUE NOT NULL,
-			user_id VARCHAR(255) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			is_deleted BOOLEAN DEFAULT FALSE
-		);
+	ctx := context.Background()
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	return s.ensureDedupIndex(ctx)
+}
+
+// migrate применяет ещё не выполненные миграции. Каждая миграция выполняется
+// в отдельной транзакции под advisory-блокировкой, чтобы несколько экземпляров
+// сервиса не применяли её одновременно.
+func (s *PostgresStore) migrate(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+
+	for i, stmt := range migrations {
+		version := i + 1
+		if err := s.applyMigration(ctx, version, stmt); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) applyMigration(ctx context.Context, version int, stmt string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))"); err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, stmt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ensureDedupIndex создаёт уникальные индексы по исходному URL, соответствующие
+// области дедупликации, и удаляет индексы других областей. Удалённые ссылки
+// в индексы не входят, поэтому URL можно сократить повторно после удаления.
+// При дедупликации в пределах пользователя ссылки рабочих пространств
+// проверяются в пределах пространства, а не автора.
+func (s *PostgresStore) ensureDedupIndex(ctx context.Context) error {
+	indexes := map[string]string{
+		globalDedupIndex:     "",
+		userDedupIndex:       "",
+		teamDedupIndex:       "",
+		legacyUserDedupIndex: "",
+	}
+	switch s.scope {
+	case models.DedupGlobal:
+		indexes[globalDedupIndex] = "ON urls (original_url) WHERE is_deleted = FALSE"
+	case models.DedupUser:
+		indexes[userDedupIndex] = "ON urls (user_id, original_url) WHERE is_deleted = FALSE AND team_id = ''"
+		indexes[teamDedupIndex] = "ON urls (team_id, original_url) WHERE is_deleted = FALSE AND team_id <> ''"
+	}
+
+	for name, definition := range indexes {
+		stmt := "DROP INDEX IF EXISTS " + name
+		if definition != "" {
+			stmt = "CREATE UNIQUE INDEX IF NOT EXISTS " + name + " " + definition
+		}
+		if _, err := s.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply dedup scope %q: %w", s.scope, err)
+		}
+	}
+	return nil
 }
 
+// isDedupViolation сообщает, что ошибка вызвана нарушением индекса дедупликации.
+func isDedupViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return false
+	}
+	switch pgErr.ConstraintName {
+	case globalDedupIndex, userDedupIndex, teamDedupIndex:
+		return true
+	}
+	return false
+}
+
+// readyTimeout ограничивает проверку соединения в Ready.
+const readyTimeout = 2 * time.Second
+
 // Ready проверяет доступность соединения с БД.
 func (s *PostgresStore) Ready() bool {
-	return s.pool.Ping(context.Background()) == nil
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+	return s.Ping(ctx) == nil
+}
+
+// Ping проверяет соединение с БД в пределах ctx.
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
 }
 
 // Save сохраняет новую пару короткий/исходный URL.
+// Возвращает ErrOriginalURLExists, если исходный URL уже сокращён
+// в пределах области дедупликации.
 func (s *PostgresStore) Save(ctx context.Context, originalURL, shortURL, userID string) error {
 	_, err := s.pool.Exec(
 		ctx,
 		"INSERT INTO urls (short_url, original_url, user_id, is_deleted) VALUES ($1, $2, $3, FALSE)",
 		shortURL, originalURL, userID,
 	)
+	if isDedupViolation(err) {
+		return ErrOriginalURLExists
+	}
 	return err
 }
 
 // GetOriginalURL возвращает исходный URL по короткому.
 func (s *PostgresStore) GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool) {
 	var originalURL, userID string
-	var deleted bool
+	var deleted, disabled bool
 	err := s.pool.QueryRow(
 		ctx,
-		"SELECT original_url, user_id, is_deleted FROM urls WHERE short_url = $1",
+		"SELECT original_url, user_id, is_deleted, is_disabled FROM urls WHERE short_url = $1",
 		shortURL,
-	).Scan(&originalURL, &userID, &deleted)
+	).Scan(&originalURL, &userID, &deleted, &disabled)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -86,27 +282,28 @@ func (s *PostgresStore) GetOriginalURL(ctx context.Context, shortURL string) (mo
 		return models.UserURLsResponse{}, false
 	}
 
-	return models.UserURLsResponse{ShortURL: shortURL, OriginalURL: originalURL, DeletedFlag: deleted}, true
+	return models.UserURLsResponse{ShortURL: shortURL, OriginalURL: originalURL, DeletedFlag: deleted, Disabled: disabled}, true
 }
 
-// GetShortURL возвращает короткий URL по исходному или ошибку, если не найден.
-func (s *PostgresStore) GetShortURL(ctx context.Context, originalURL string) (string, error) {
-	var shortURL string
+// GetShortURL возвращает запись по исходному URL или ошибку, если она не найдена.
+// Если userID не пуст, поиск ведётся только среди ссылок этого пользователя.
+func (s *PostgresStore) GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error) {
+	record := models.URLRecord{OriginalURL: originalURL}
 
 	err := s.pool.QueryRow(
 		ctx,
-		"SELECT short_url FROM urls WHERE original_url = $1 AND is_deleted = FALSE",
-		originalURL,
-	).Scan(&shortURL)
+		"SELECT short_url, user_id, team_id FROM urls WHERE original_url = $1 AND (user_id = $2 AND team_id = '' OR $2 = '') AND is_deleted = FALSE LIMIT 1",
+		originalURL, userID,
+	).Scan(&record.ShortURL, &record.UserID, &record.TeamID)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", ErrShortURLNotFound
+			return models.URLRecord{}, ErrShortURLNotFound
 		}
-		return "", fmt.Errorf("database error: %w", err)
+		return models.URLRecord{}, fmt.Errorf("database error: %w", err)
 	}
 
-	return shortURL, nil
+	return record, nil
 }
 
 // SaveBatch сохраняет набор записей в транзакции.
@@ -143,7 +340,7 @@ func (s *PostgresStore) SaveBatch(records []models.URLRecord) error {
 func (s *PostgresStore) GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error) {
 	rows, err := s.pool.Query(
 		ctx,
-		"SELECT short_url, original_url FROM urls WHERE user_id = $1 AND is_deleted = FALSE",
+		"SELECT short_url, original_url FROM urls WHERE user_id = $1 AND team_id = '' AND is_deleted = FALSE",
 		userID,
 	)
 	if err != nil {
@@ -172,7 +369,473 @@ func (s *PostgresStore) DeleteUserURLs(ctx context.Context, userID string, ids [
 	if len(ids) == 0 {
 		return nil
 	}
-	_, err := s.pool.Exec(ctx, "UPDATE urls SET is_deleted = TRUE WHERE user_id = $1 AND short_url = ANY($2)", userID, ids)
+	_, err := s.pool.Exec(ctx, "UPDATE urls SET is_deleted = TRUE WHERE user_id = $1 AND team_id = '' AND short_url = ANY($2)", userID, ids)
+	return err
+}
+
+// SaveAPIKey сохраняет API-ключ.
+func (s *PostgresStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.CreatedAt,
+	)
+	return err
+}
+
+// GetAPIKeyByHash возвращает API-ключ по его хэшу.
+func (s *PostgresStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	key := models.APIKey{Hash: hash}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT id, user_id, name, prefix, created_at FROM api_keys WHERE key_hash = $1",
+		hash,
+	).Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("database error: %w", err)
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys возвращает API-ключи пользователя.
+func (s *PostgresStore) GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	rows, err := s.pool.Query(
+		ctx,
+		"SELECT id, user_id, name, prefix, key_hash, created_at FROM api_keys WHERE user_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteUserAPIKeys удаляет API-ключи пользователя с указанными идентификаторами.
+func (s *PostgresStore) DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, "DELETE FROM api_keys WHERE user_id = $1 AND id = ANY($2)", userID, ids)
+	return err
+}
+
+// CreateUser сохраняет новую учётную запись.
+// Возвращает ErrUserExists, если email уже занят.
+func (s *PostgresStore) CreateUser(ctx context.Context, user models.User) error {
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO users (id, email, password_hash, created_at) VALUES ($1, $2, $3, $4)",
+		user.ID, user.Email, user.PasswordHash, user.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return ErrUserExists
+	}
+	return err
+}
+
+// GetUserByEmail возвращает учётную запись по email.
+func (s *PostgresStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	user := models.User{Email: email}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT id, password_hash, created_at FROM users WHERE email = $1",
+		email,
+	).Scan(&user.ID, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("database error: %w", err)
+	}
+	return user, nil
+}
+
+// SaveSession сохраняет сессию.
+func (s *PostgresStore) SaveSession(ctx context.Context, session models.Session) error {
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		session.Hash, session.UserID, session.CreatedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+// GetSession возвращает сессию по хэшу токена.
+func (s *PostgresStore) GetSession(ctx context.Context, hash string) (models.Session, error) {
+	session := models.Session{Hash: hash}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT user_id, created_at, expires_at FROM sessions WHERE token_hash = $1",
+		hash,
+	).Scan(&session.UserID, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return models.Session{}, fmt.Errorf("database error: %w", err)
+	}
+	return session, nil
+}
+
+// DeleteSession удаляет сессию.
+func (s *PostgresStore) DeleteSession(ctx context.Context, hash string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM sessions WHERE token_hash = $1", hash)
+	return err
+}
+
+// TransferUserURLs передаёт ссылки пользователя fromUserID пользователю toUserID.
+// Ссылки на URL, которые уже есть у toUserID, остаются у прежнего владельца.
+func (s *PostgresStore) TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error) {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE urls SET user_id = $2
+		WHERE user_id = $1 AND team_id = '' AND is_deleted = FALSE
+			AND NOT EXISTS (
+				SELECT 1 FROM urls owned
+				WHERE owned.user_id = $2 AND owned.team_id = '' AND owned.original_url = urls.original_url AND owned.is_deleted = FALSE
+			)`,
+		fromUserID, toUserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchURLs возвращает ссылки всех пользователей, подходящие под фильтр.
+func (s *PostgresStore) SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error) {
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT uuid, short_url, original_url, user_id, COALESCE(is_deleted, FALSE), is_disabled, team_id
+		FROM urls
+		WHERE ($1 = '' OR user_id = $1)
+			AND ($2 OR is_deleted = FALSE)
+			AND ($3 = '' OR short_url ILIKE '%' || $3 || '%' OR original_url ILIKE '%' || $3 || '%')
+		ORDER BY short_url
+		LIMIT $4 OFFSET $5`,
+		filter.UserID, filter.IncludeDeleted, likeEscaper.Replace(filter.Query), limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.URLRecord
+	for rows.Next() {
+		var record models.URLRecord
+		var id int64
+		if err := rows.Scan(&id, &record.ShortURL, &record.OriginalURL, &record.UserID, &record.DeletedFlag, &record.Disabled, &record.TeamID); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		record.UUID = strconv.FormatInt(id, 10)
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return records, nil
+}
+
+// GetURL возвращает запись по короткому ключу.
+func (s *PostgresStore) GetURL(ctx context.Context, shortURL string) (models.URLRecord, error) {
+	record := models.URLRecord{ShortURL: shortURL}
+	var id int64
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT uuid, original_url, user_id, COALESCE(is_deleted, FALSE), is_disabled, team_id FROM urls WHERE short_url = $1",
+		shortURL,
+	).Scan(&id, &record.OriginalURL, &record.UserID, &record.DeletedFlag, &record.Disabled, &record.TeamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.URLRecord{}, ErrShortURLNotFound
+	}
+	if err != nil {
+		return models.URLRecord{}, fmt.Errorf("database error: %w", err)
+	}
+	record.UUID = strconv.FormatInt(id, 10)
+	return record, nil
+}
+
+// SetURLDisabled отключает или включает ссылку.
+func (s *PostgresStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE urls SET is_disabled = $2 WHERE short_url = $1", shortURL, disabled)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShortURLNotFound
+	}
+	return nil
+}
+
+// SetURLOwner назначает ссылке нового владельца. Возвращает ErrOriginalURLExists,
+// если у нового владельца уже есть ссылка на тот же URL, а дедупликация
+// действует в пределах пользователя.
+func (s *PostgresStore) SetURLOwner(ctx context.Context, shortURL string, userID string) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE urls SET user_id = $2 WHERE short_url = $1", shortURL, userID)
+	if isDedupViolation(err) {
+		return ErrOriginalURLExists
+	}
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShortURLNotFound
+	}
+	return nil
+}
+
+// SaveTeamURL сохраняет ссылку рабочего пространства teamID, созданную userID.
+func (s *PostgresStore) SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error {
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO urls (short_url, original_url, user_id, team_id, is_deleted) VALUES ($1, $2, $3, $4, FALSE)",
+		shortURL, originalURL, userID, teamID,
+	)
+	if isDedupViolation(err) {
+		return ErrOriginalURLExists
+	}
+	return err
+}
+
+// GetTeamShortURL возвращает ссылку рабочего пространства по исходному URL.
+func (s *PostgresStore) GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error) {
+	record := models.URLRecord{OriginalURL: originalURL, TeamID: teamID}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT short_url, user_id FROM urls WHERE team_id = $1 AND original_url = $2 AND is_deleted = FALSE LIMIT 1",
+		teamID, originalURL,
+	).Scan(&record.ShortURL, &record.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.URLRecord{}, ErrShortURLNotFound
+	}
+	if err != nil {
+		return models.URLRecord{}, fmt.Errorf("database error: %w", err)
+	}
+	return record, nil
+}
+
+// GetTeamURLs возвращает неудалённые ссылки рабочего пространства.
+func (s *PostgresStore) GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error) {
+	rows, err := s.pool.Query(
+		ctx,
+		"SELECT short_url, original_url, user_id, is_disabled FROM urls WHERE team_id = $1 AND is_deleted = FALSE ORDER BY short_url",
+		teamID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.URLRecord
+	for rows.Next() {
+		record := models.URLRecord{TeamID: teamID}
+		if err := rows.Scan(&record.ShortURL, &record.OriginalURL, &record.UserID, &record.Disabled); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return records, nil
+}
+
+// UpdateTeamURL меняет исходный URL ссылки рабочего пространства.
+func (s *PostgresStore) UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error {
+	tag, err := s.pool.Exec(
+		ctx,
+		"UPDATE urls SET original_url = $3 WHERE team_id = $1 AND short_url = $2 AND is_deleted = FALSE",
+		teamID, shortURL, originalURL,
+	)
+	if isDedupViolation(err) {
+		return ErrOriginalURLExists
+	}
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShortURLNotFound
+	}
+	return nil
+}
+
+// DeleteTeamURLs помечает как удалённые ссылки рабочего пространства.
+func (s *PostgresStore) DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, "UPDATE urls SET is_deleted = TRUE WHERE team_id = $1 AND short_url = ANY($2)", teamID, ids)
+	return err
+}
+
+// CreateTeam создаёт рабочее пространство вместе с его первым участником в одной транзакции.
+func (s *PostgresStore) CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "INSERT INTO teams (id, name, created_at) VALUES ($1, $2, $3)",
+		team.ID, team.Name, team.CreatedAt); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO team_members (team_id, user_id, role, joined_at) VALUES ($1, $2, $3, $4)",
+		owner.TeamID, owner.UserID, owner.Role, owner.JoinedAt); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// GetTeam возвращает рабочее пространство по идентификатору.
+func (s *PostgresStore) GetTeam(ctx context.Context, teamID string) (models.Team, error) {
+	team := models.Team{ID: teamID}
+	err := s.pool.QueryRow(ctx, "SELECT name, created_at FROM teams WHERE id = $1", teamID).Scan(&team.Name, &team.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Team{}, ErrTeamNotFound
+	}
+	if err != nil {
+		return models.Team{}, fmt.Errorf("database error: %w", err)
+	}
+	return team, nil
+}
+
+// GetUserTeams возвращает рабочие пространства пользователя с его ролями.
+func (s *PostgresStore) GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.id, t.name, m.role
+		FROM team_members m JOIN teams t ON t.id = m.team_id
+		WHERE m.user_id = $1
+		ORDER BY t.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []models.TeamResponse
+	for rows.Next() {
+		var team models.TeamResponse
+		if err := rows.Scan(&team.ID, &team.Name, &team.Role); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return teams, nil
+}
+
+// GetTeamMember возвращает участие пользователя в рабочем пространстве.
+func (s *PostgresStore) GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error) {
+	member := models.TeamMember{TeamID: teamID, UserID: userID}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT role, joined_at FROM team_members WHERE team_id = $1 AND user_id = $2",
+		teamID, userID,
+	).Scan(&member.Role, &member.JoinedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.TeamMember{}, ErrTeamMemberNotFound
+	}
+	if err != nil {
+		return models.TeamMember{}, fmt.Errorf("database error: %w", err)
+	}
+	return member, nil
+}
+
+// GetTeamMembers возвращает участников рабочего пространства.
+func (s *PostgresStore) GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error) {
+	rows, err := s.pool.Query(ctx, "SELECT user_id, role, joined_at FROM team_members WHERE team_id = $1", teamID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.TeamMember
+	for rows.Next() {
+		member := models.TeamMember{TeamID: teamID}
+		if err := rows.Scan(&member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return members, nil
+}
+
+// SaveTeamMember добавляет участника или меняет его роль.
+func (s *PostgresStore) SaveTeamMember(ctx context.Context, member models.TeamMember) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO team_members (team_id, user_id, role, joined_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role`,
+		member.TeamID, member.UserID, member.Role, member.JoinedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return ErrTeamNotFound
+	}
+	return err
+}
+
+// DeleteTeamMember исключает пользователя из рабочего пространства.
+func (s *PostgresStore) DeleteTeamMember(ctx context.Context, teamID string, userID string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM team_members WHERE team_id = $1 AND user_id = $2", teamID, userID)
+	return err
+}
+
+// SaveTeamInvite сохраняет приглашение.
+func (s *PostgresStore) SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error {
+	_, err := s.pool.Exec(
+		ctx,
+		"INSERT INTO team_invites (token_hash, team_id, role, created_by, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		invite.Hash, invite.TeamID, invite.Role, invite.CreatedBy, invite.ExpiresAt,
+	)
+	return err
+}
+
+// GetTeamInvite возвращает приглашение по хэшу токена.
+func (s *PostgresStore) GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error) {
+	invite := models.TeamInvite{Hash: hash}
+	err := s.pool.QueryRow(
+		ctx,
+		"SELECT team_id, role, created_by, expires_at FROM team_invites WHERE token_hash = $1",
+		hash,
+	).Scan(&invite.TeamID, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.TeamInvite{}, ErrTeamInviteNotFound
+	}
+	if err != nil {
+		return models.TeamInvite{}, fmt.Errorf("database error: %w", err)
+	}
+	return invite, nil
+}
+
+// DeleteTeamInvite удаляет приглашение.
+func (s *PostgresStore) DeleteTeamInvite(ctx context.Context, hash string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM team_invites WHERE token_hash = $1", hash)
 	return err
 }
 
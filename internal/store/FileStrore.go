@@ -4,24 +4,55 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 )
 
 // FileStore реализует файловое хранилище ссылок в формате JSONL.
+// Дополнительные сущности хранятся в отдельных JSONL-файлах рядом с основным.
 type FileStore struct {
+	scope    models.DedupScope
 	mu       sync.RWMutex
 	db       map[string]models.URLRecord
 	file     *os.File
 	writer   *bufio.Writer
 	nextUUID int
+
+	apiKeys      map[string]models.APIKey
+	keysFile     *jsonlFile[models.APIKey]
+	users        map[string]models.User
+	usersFile    *jsonlFile[models.User]
+	sessions     map[string]models.Session
+	sessionsFile *jsonlFile[models.Session]
+	teams        map[string]models.Team
+	teamsFile    *jsonlFile[models.Team]
+	members      map[string]map[string]models.TeamMember
+	membersFile  *jsonlFile[models.TeamMember]
+	invites      map[string]models.TeamInvite
+	invitesFile  *jsonlFile[models.TeamInvite]
 }
 
+// Суффиксы файлов с дополнительными сущностями относительно основного файла.
+const (
+	apiKeysFileSuffix  = ".apikeys"
+	usersFileSuffix    = ".users"
+	sessionsFileSuffix = ".sessions"
+	teamsFileSuffix    = ".teams"
+	membersFileSuffix  = ".team_members"
+	invitesFileSuffix  = ".team_invites"
+)
+
 // NewFileStore открывает/создаёт файл и загружает существующие записи.
-func NewFileStore(filePath string) (*FileStore, error) {
+// Повторное сохранение исходного URL отклоняется в соответствии с областью
+// дедупликации scope.
+func NewFileStore(filePath string, scope models.DedupScope) (*FileStore, error) {
 	// Открываем файл для чтения и записи (создаем если не существует)
 	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -29,9 +60,16 @@ func NewFileStore(filePath string) (*FileStore, error) {
 	}
 
 	store := &FileStore{
-		db:     make(map[string]models.URLRecord),
-		file:   file,
-		writer: bufio.NewWriter(file),
+		scope:    scope,
+		db:       make(map[string]models.URLRecord),
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		apiKeys:  make(map[string]models.APIKey),
+		users:    make(map[string]models.User),
+		sessions: make(map[string]models.Session),
+		teams:    make(map[string]models.Team),
+		members:  make(map[string]map[string]models.TeamMember),
+		invites:  make(map[string]models.TeamInvite),
 	}
 
 	// Загружаем существующие данные из файла
@@ -40,35 +78,87 @@ func NewFileStore(filePath string) (*FileStore, error) {
 		return nil, err
 	}
 
+	if err := store.openAuxFiles(filePath); err != nil {
+		store.Close()
+		return nil, err
+	}
+
 	return store, nil
 }
 
-// Save сохраняет новую запись в памяти и файле.
+// openAuxFiles открывает файлы дополнительных сущностей и загружает их в память.
+// Истёкшие сессии и приглашения при загрузке отбрасываются. Участники
+// рабочих пространств дописываются в файл при каждом изменении, поэтому
+// при загрузке действует последняя запись.
+func (s *FileStore) openAuxFiles(filePath string) error {
+	var err error
+	s.keysFile, err = openJSONL(filePath+apiKeysFileSuffix, func(key models.APIKey) {
+		s.apiKeys[key.Hash] = key
+	})
+	if err != nil {
+		return err
+	}
+	s.usersFile, err = openJSONL(filePath+usersFileSuffix, func(user models.User) {
+		s.users[user.Email] = user
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s.sessionsFile, err = openJSONL(filePath+sessionsFileSuffix, func(session models.Session) {
+		if session.ExpiresAt.After(now) {
+			s.sessions[session.Hash] = session
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.teamsFile, err = openJSONL(filePath+teamsFileSuffix, func(team models.Team) {
+		s.teams[team.ID] = team
+	})
+	if err != nil {
+		return err
+	}
+	s.membersFile, err = openJSONL(filePath+membersFileSuffix, func(member models.TeamMember) {
+		if s.members[member.TeamID] == nil {
+			s.members[member.TeamID] = make(map[string]models.TeamMember)
+		}
+		s.members[member.TeamID][member.UserID] = member
+	})
+	if err != nil {
+		return err
+	}
+	s.invitesFile, err = openJSONL(filePath+invitesFileSuffix, func(invite models.TeamInvite) {
+		if invite.ExpiresAt.After(now) {
+			s.invites[invite.Hash] = invite
+		}
+	})
+	return err
+}
+
+// Save сохраняет новую запись в памяти и файле. Возвращает
+// *ExistingURLError, если URL уже сокращён в пределах области дедупликации.
 func (s *FileStore) Save(ctx context.Context, originalURL, shortURL, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if existing, found := findDuplicate(s.db, s.scope, originalURL, userID, "", ""); found {
+		return &ExistingURLError{Record: existing}
+	}
+	return s.appendRecord(models.URLRecord{
+		ShortURL:    shortURL,
+		OriginalURL: originalURL,
+		UserID:      userID,
+	})
+}
 
+// appendRecord присваивает записи UUID, сохраняет её в памяти и дописывает в файл.
+func (s *FileStore) appendRecord(record models.URLRecord) error {
 	// Генерируем новый UUID
 	s.nextUUID++
-	uuid := strconv.Itoa(s.nextUUID)
+	record.UUID = strconv.Itoa(s.nextUUID)
 
 	// Сохраняем в памяти
-	s.db[shortURL] = models.URLRecord{
-		UUID:        uuid,
-		ShortURL:    shortURL,
-		OriginalURL: originalURL,
-		UserID:      userID,
-		DeletedFlag: false,
-	}
-
-	// Создаем запись для сохранения
-	record := models.URLRecord{
-		UUID:        uuid,
-		ShortURL:    shortURL,
-		OriginalURL: originalURL,
-		UserID:      userID,
-		DeletedFlag: false,
-	}
+	s.db[record.ShortURL] = record
 
 	// Кодируем в JSON
 	data, err := json.Marshal(record)
@@ -97,20 +187,26 @@ func (s *FileStore) GetOriginalURL(ctx context.Context, shortURL string) (models
 	if !found {
 		return models.UserURLsResponse{}, false
 	}
-	return models.UserURLsResponse{ShortURL: record.ShortURL, OriginalURL: record.OriginalURL, DeletedFlag: record.DeletedFlag}, true
+	return models.UserURLsResponse{
+		ShortURL:    record.ShortURL,
+		OriginalURL: record.OriginalURL,
+		DeletedFlag: record.DeletedFlag,
+		Disabled:    record.Disabled,
+	}, true
 }
 
-// GetShortURL возвращает короткий ключ по исходному URL или ошибку, если не найден.
-func (s *FileStore) GetShortURL(ctx context.Context, originalURL string) (string, error) {
+// GetShortURL возвращает запись по исходному URL или ошибку, если она не найдена.
+// Если userID не пуст, поиск ведётся только среди ссылок этого пользователя.
+func (s *FileStore) GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	for k, v := range s.db {
-		if v.OriginalURL == originalURL && !v.DeletedFlag {
-			return k, nil
+	for _, v := range s.db {
+		if v.OriginalURL == originalURL && !v.DeletedFlag && (userID == "" || v.UserID == userID && v.TeamID == "") {
+			return v, nil
 		}
 	}
 
-	return "", ErrShortURLNotFound
+	return models.URLRecord{}, ErrShortURLNotFound
 }
 
 // Close закрывает файловые ресурсы хранилища.
@@ -121,6 +217,20 @@ func (s *FileStore) Close() error {
 	if err := s.writer.Flush(); err != nil {
 		return err
 	}
+	if err := s.keysFile.Close(); err != nil {
+		return err
+	}
+	if err := s.usersFile.Close(); err != nil {
+		return err
+	}
+	if err := s.sessionsFile.Close(); err != nil {
+		return err
+	}
+	for _, f := range []interface{ Close() error }{s.teamsFile, s.membersFile, s.invitesFile} {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
 	return s.file.Close()
 }
 
@@ -150,6 +260,34 @@ func (s *FileStore) Ready() bool {
 	return true
 }
 
+// Ping проверяет, что файл данных по-прежнему лежит по своему пути (не был
+// удалён или подменён) и доступен процессу на запись.
+func (s *FileStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	opened, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	path := s.file.Name()
+	onDisk, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(opened, onDisk) {
+		return fmt.Errorf("%s was replaced or removed", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
 // SaveBatch сохраняет набор записей в файл.
 func (s *FileStore) SaveBatch(records []models.URLRecord) error {
 	s.mu.Lock()
@@ -168,7 +306,7 @@ func (s *FileStore) GetUserURLs(ctx context.Context, userID string) ([]models.Us
 
 	var urls []models.UserURLsResponse
 	for _, record := range s.db {
-		if record.UserID == userID && !record.DeletedFlag {
+		if record.UserID == userID && record.TeamID == "" && !record.DeletedFlag {
 			urls = append(urls, models.UserURLsResponse{
 				ShortURL:    record.ShortURL,
 				OriginalURL: record.OriginalURL,
@@ -185,7 +323,7 @@ func (s *FileStore) DeleteUserURLs(ctx context.Context, userID string, ids []str
 	changed := false
 	for _, id := range ids {
 		record, ok := s.db[id]
-		if ok && record.UserID == userID && !record.DeletedFlag {
+		if ok && record.UserID == userID && record.TeamID == "" && !record.DeletedFlag {
 			record.DeletedFlag = true
 			s.db[id] = record
 			changed = true
@@ -209,3 +347,345 @@ func (s *FileStore) saveAllToFile() {
 	}
 	s.writer.Flush()
 }
+
+// SaveAPIKey сохраняет API-ключ в памяти и файле ключей.
+func (s *FileStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.keysFile.Append(key); err != nil {
+		return err
+	}
+	s.apiKeys[key.Hash] = key
+	return nil
+}
+
+// GetAPIKeyByHash возвращает API-ключ по его хэшу.
+func (s *FileStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, found := s.apiKeys[hash]
+	if !found {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys возвращает API-ключи пользователя.
+func (s *FileStore) GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []models.APIKey
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// DeleteUserAPIKeys удаляет API-ключи пользователя и перезаписывает файл ключей.
+func (s *FileStore) DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for hash, key := range s.apiKeys {
+		if key.UserID == userID && slices.Contains(ids, key.ID) {
+			delete(s.apiKeys, hash)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.keysFile.Rewrite(slices.Collect(maps.Values(s.apiKeys)))
+}
+
+// CreateUser сохраняет новую учётную запись.
+// Возвращает ErrUserExists, если email уже занят.
+func (s *FileStore) CreateUser(ctx context.Context, user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.users[user.Email]; found {
+		return ErrUserExists
+	}
+	if err := s.usersFile.Append(user); err != nil {
+		return err
+	}
+	s.users[user.Email] = user
+	return nil
+}
+
+// GetUserByEmail возвращает учётную запись по email.
+func (s *FileStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, found := s.users[email]
+	if !found {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// SaveSession сохраняет сессию в памяти и файле сессий.
+func (s *FileStore) SaveSession(ctx context.Context, session models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.sessionsFile.Append(session); err != nil {
+		return err
+	}
+	s.sessions[session.Hash] = session
+	return nil
+}
+
+// GetSession возвращает сессию по хэшу токена.
+func (s *FileStore) GetSession(ctx context.Context, hash string) (models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, found := s.sessions[hash]
+	if !found {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// DeleteSession удаляет сессию и перезаписывает файл сессий.
+func (s *FileStore) DeleteSession(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.sessions[hash]; !found {
+		return nil
+	}
+	delete(s.sessions, hash)
+	return s.sessionsFile.Rewrite(slices.Collect(maps.Values(s.sessions)))
+}
+
+// TransferUserURLs передаёт ссылки пользователя fromUserID пользователю toUserID
+// и перезаписывает файл. Ссылки на URL, которые уже есть у toUserID,
+// остаются у прежнего владельца.
+func (s *FileStore) TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := transferURLs(s.db, fromUserID, toUserID)
+	if count > 0 {
+		s.saveAllToFile()
+	}
+	return count, nil
+}
+
+// SearchURLs возвращает ссылки всех пользователей, подходящие под фильтр.
+func (s *FileStore) SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return searchURLs(s.db, filter), nil
+}
+
+// GetURL возвращает запись по короткому ключу.
+func (s *FileStore) GetURL(ctx context.Context, shortURL string) (models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return models.URLRecord{}, ErrShortURLNotFound
+	}
+	return record, nil
+}
+
+// SetURLDisabled отключает или включает ссылку и перезаписывает файл.
+func (s *FileStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return ErrShortURLNotFound
+	}
+	if record.Disabled != disabled {
+		record.Disabled = disabled
+		s.db[shortURL] = record
+		s.saveAllToFile()
+	}
+	return nil
+}
+
+// SetURLOwner назначает ссылке нового владельца и перезаписывает файл.
+// Возвращает ErrOriginalURLExists, если у нового владельца уже есть ссылка
+// на тот же URL, а дедупликация действует в пределах пользователя.
+func (s *FileStore) SetURLOwner(ctx context.Context, shortURL string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return ErrShortURLNotFound
+	}
+	if _, found := findDuplicate(s.db, s.scope, record.OriginalURL, userID, record.TeamID, shortURL); found && !record.DeletedFlag {
+		return ErrOriginalURLExists
+	}
+	if record.UserID != userID {
+		record.UserID = userID
+		s.db[shortURL] = record
+		s.saveAllToFile()
+	}
+	return nil
+}
+
+// SaveTeamURL сохраняет ссылку рабочего пространства teamID, созданную userID.
+func (s *FileStore) SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, found := findDuplicate(s.db, s.scope, originalURL, userID, teamID, ""); found {
+		return &ExistingURLError{Record: existing}
+	}
+	return s.appendRecord(models.URLRecord{
+		ShortURL:    shortURL,
+		OriginalURL: originalURL,
+		UserID:      userID,
+		TeamID:      teamID,
+	})
+}
+
+// GetTeamShortURL возвращает ссылку рабочего пространства по исходному URL.
+func (s *FileStore) GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return teamShortURL(s.db, teamID, originalURL)
+}
+
+// GetTeamURLs возвращает неудалённые ссылки рабочего пространства.
+func (s *FileStore) GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return teamURLs(s.db, teamID), nil
+}
+
+// UpdateTeamURL меняет исходный URL ссылки рабочего пространства и перезаписывает файл.
+func (s *FileStore) UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := updateTeamURL(s.db, s.scope, teamID, shortURL, originalURL); err != nil {
+		return err
+	}
+	s.saveAllToFile()
+	return nil
+}
+
+// DeleteTeamURLs помечает как удалённые ссылки рабочего пространства и перезаписывает файл.
+func (s *FileStore) DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if deleteTeamURLs(s.db, teamID, ids) {
+		s.saveAllToFile()
+	}
+	return nil
+}
+
+// CreateTeam создаёт рабочее пространство вместе с его первым участником.
+func (s *FileStore) CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.teamsFile.Append(team); err != nil {
+		return err
+	}
+	if err := s.membersFile.Append(owner); err != nil {
+		return err
+	}
+	s.teams[team.ID] = team
+	s.members[team.ID] = map[string]models.TeamMember{owner.UserID: owner}
+	return nil
+}
+
+// GetTeam возвращает рабочее пространство по идентификатору.
+func (s *FileStore) GetTeam(ctx context.Context, teamID string) (models.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	team, found := s.teams[teamID]
+	if !found {
+		return models.Team{}, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// GetUserTeams возвращает рабочие пространства пользователя с его ролями.
+func (s *FileStore) GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return userTeams(s.teams, s.members, userID), nil
+}
+
+// GetTeamMember возвращает участие пользователя в рабочем пространстве.
+func (s *FileStore) GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	member, found := s.members[teamID][userID]
+	if !found {
+		return models.TeamMember{}, ErrTeamMemberNotFound
+	}
+	return member, nil
+}
+
+// GetTeamMembers возвращает участников рабочего пространства.
+func (s *FileStore) GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Collect(maps.Values(s.members[teamID])), nil
+}
+
+// SaveTeamMember добавляет участника или меняет его роль.
+func (s *FileStore) SaveTeamMember(ctx context.Context, member models.TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.teams[member.TeamID]; !found {
+		return ErrTeamNotFound
+	}
+	if err := s.membersFile.Append(member); err != nil {
+		return err
+	}
+	s.members[member.TeamID][member.UserID] = member
+	return nil
+}
+
+// DeleteTeamMember исключает пользователя из рабочего пространства и перезаписывает файл участников.
+func (s *FileStore) DeleteTeamMember(ctx context.Context, teamID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.members[teamID][userID]; !found {
+		return nil
+	}
+	delete(s.members[teamID], userID)
+	var all []models.TeamMember
+	for _, teamMembers := range s.members {
+		all = slices.AppendSeq(all, maps.Values(teamMembers))
+	}
+	return s.membersFile.Rewrite(all)
+}
+
+// SaveTeamInvite сохраняет приглашение в памяти и файле приглашений.
+func (s *FileStore) SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.invitesFile.Append(invite); err != nil {
+		return err
+	}
+	s.invites[invite.Hash] = invite
+	return nil
+}
+
+// GetTeamInvite возвращает приглашение по хэшу токена.
+func (s *FileStore) GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	invite, found := s.invites[hash]
+	if !found {
+		return models.TeamInvite{}, ErrTeamInviteNotFound
+	}
+	return invite, nil
+}
+
+// DeleteTeamInvite удаляет приглашение и перезаписывает файл приглашений.
+func (s *FileStore) DeleteTeamInvite(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.invites[hash]; !found {
+		return nil
+	}
+	delete(s.invites, hash)
+	return s.invitesFile.Rewrite(slices.Collect(maps.Values(s.invites)))
+}
@@ -8,30 +8,115 @@ import (
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 )
 
-// ErrShortURLNotFound возвращается, когда короткий ключ для исходного URL не найден.
 var (
+	// ErrShortURLNotFound возвращается, когда короткий ключ для исходного URL не найден.
 	ErrShortURLNotFound = errors.New("short URL not found")
+	// ErrOriginalURLExists возвращается при сохранении, если исходный URL уже
+	// сокращён в пределах действующей области дедупликации.
+	ErrOriginalURLExists = errors.New("original URL already exists")
+	// ErrAPIKeyNotFound возвращается, когда API-ключ не найден.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrUserNotFound возвращается, когда учётная запись не найдена.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists возвращается при регистрации, если email уже занят.
+	ErrUserExists = errors.New("user already exists")
+	// ErrSessionNotFound возвращается, когда сессия не найдена.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrTeamNotFound возвращается, когда рабочее пространство не найдено.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrTeamMemberNotFound возвращается, когда пользователь не состоит в рабочем пространстве.
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	// ErrTeamInviteNotFound возвращается, когда приглашение не найдено.
+	ErrTeamInviteNotFound = errors.New("team invite not found")
 )
 
+// ExistingURLError — ErrOriginalURLExists вместе с уже сохранённой записью.
+// Её возвращают хранилища, которые знают конфликтующую запись в момент
+// сохранения; PostgresStore возвращает просто ErrOriginalURLExists.
+type ExistingURLError struct {
+	Record models.URLRecord
+}
+
+// Error возвращает описание ошибки с ключом существующей ссылки.
+func (e *ExistingURLError) Error() string {
+	return ErrOriginalURLExists.Error() + ": " + e.Record.ShortURL
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrOriginalURLExists).
+func (e *ExistingURLError) Unwrap() error {
+	return ErrOriginalURLExists
+}
+
 // Store описывает контракт хранилища для разных реализаций.
 type Store interface {
 	Save(ctx context.Context, originalURL string, shortURL string, userID string) error
 	GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool)
 	Ready() bool
-	GetShortURL(ctx context.Context, shortURL string) (string, error)
+	Ping(ctx context.Context) error
+	GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error)
 	GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error)
 	DeleteUserURLs(ctx context.Context, userID string, ids []string) error
+	SaveAPIKey(ctx context.Context, key models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error
+	CreateUser(ctx context.Context, user models.User) error
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	SaveSession(ctx context.Context, session models.Session) error
+	GetSession(ctx context.Context, hash string) (models.Session, error)
+	DeleteSession(ctx context.Context, hash string) error
+	TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error)
+	SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error)
+	GetURL(ctx context.Context, shortURL string) (models.URLRecord, error)
+	SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error
+	SetURLOwner(ctx context.Context, shortURL string, userID string) error
+	SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error
+	GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error)
+	GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error)
+	UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error
+	DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error
+	CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error
+	GetTeam(ctx context.Context, teamID string) (models.Team, error)
+	GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error)
+	GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error)
+	GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error)
+	SaveTeamMember(ctx context.Context, member models.TeamMember) error
+	DeleteTeamMember(ctx context.Context, teamID string, userID string) error
+	SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error
+	GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error)
+	DeleteTeamInvite(ctx context.Context, hash string) error
 	Close() error
 }
 
-// InitStore инициализирует подходящее хранилище в зависимости от конфигурации.
-func InitStore(cfg *config.Config) (Store, error) {
+// Названия хранилищ, возвращаемые Backend.
+const (
+	BackendPostgres = "postgres"
+	BackendFile     = "file"
+	BackendMemory   = "memory"
+)
+
+// Backend возвращает название хранилища, которое InitStore выберет для cfg.
+func Backend(cfg *config.Config) string {
 	switch {
 	case cfg.ConnectionString != "":
-		return NewDBStore(cfg.ConnectionString)
+		return BackendPostgres
 	case cfg.File != "":
-		return NewFileStore(cfg.File)
+		return BackendFile
+	default:
+		return BackendMemory
+	}
+}
+
+// InitStore инициализирует подходящее хранилище в зависимости от конфигурации.
+// Область дедупликации scope передаётся уже разобранной, чтобы хранилище
+// и сервис использовали одно и то же значение.
+func InitStore(cfg *config.Config, scope models.DedupScope) (Store, error) {
+	switch Backend(cfg) {
+	case BackendPostgres:
+		return NewDBStore(cfg.ConnectionString, scope)
+	case BackendFile:
+		return NewFileStore(cfg.File, scope)
 	default:
-		return NewInMemoryStore(), nil
+		return NewInMemoryStore(scope), nil
 	}
 }
@@ -2,22 +2,50 @@ package store
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/AlexeySalamakhin/URLShortener/internal/models"
 )
 
 // InMemoryStore хранит данные в памяти процесса.
 type InMemoryStore struct {
-	db map[string]models.URLRecord
+	scope    models.DedupScope
+	mu       sync.RWMutex
+	db       map[string]models.URLRecord
+	apiKeys  map[string]models.APIKey
+	users    map[string]models.User
+	sessions map[string]models.Session
+	teams    map[string]models.Team
+	members  map[string]map[string]models.TeamMember
+	invites  map[string]models.TeamInvite
 }
 
-// NewInMemoryStore создаёт новое in-memory хранилище.
-func NewInMemoryStore() *InMemoryStore {
-	return &InMemoryStore{db: make(map[string]models.URLRecord)}
+// NewInMemoryStore создаёт новое in-memory хранилище. Повторное сохранение
+// исходного URL отклоняется в соответствии с областью дедупликации scope.
+func NewInMemoryStore(scope models.DedupScope) *InMemoryStore {
+	return &InMemoryStore{
+		scope:    scope,
+		db:       make(map[string]models.URLRecord),
+		apiKeys:  make(map[string]models.APIKey),
+		users:    make(map[string]models.User),
+		sessions: make(map[string]models.Session),
+		teams:    make(map[string]models.Team),
+		members:  make(map[string]map[string]models.TeamMember),
+		invites:  make(map[string]models.TeamInvite),
+	}
 }
 
-// Save сохраняет пару короткий/исходный URL в памяти.
+// Save сохраняет пару короткий/исходный URL в памяти. Возвращает
+// *ExistingURLError, если URL уже сокращён в пределах области дедупликации.
 func (s *InMemoryStore) Save(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, found := findDuplicate(s.db, s.scope, originalURL, userID, "", ""); found {
+		return &ExistingURLError{Record: existing}
+	}
 	s.db[shortURL] = models.URLRecord{
 		ShortURL:    shortURL,
 		OriginalURL: originalURL,
@@ -29,22 +57,32 @@ func (s *InMemoryStore) Save(ctx context.Context, originalURL string, shortURL s
 
 // GetOriginalURL возвращает исходный URL по короткому ключу.
 func (s *InMemoryStore) GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	record, found := s.db[shortURL]
 	if !found {
 		return models.UserURLsResponse{}, false
 	}
-	return models.UserURLsResponse{ShortURL: record.ShortURL, OriginalURL: record.OriginalURL, DeletedFlag: record.DeletedFlag}, true
+	return models.UserURLsResponse{
+		ShortURL:    record.ShortURL,
+		OriginalURL: record.OriginalURL,
+		DeletedFlag: record.DeletedFlag,
+		Disabled:    record.Disabled,
+	}, true
 }
 
-// GetShortURL возвращает короткий ключ по исходному URL или ошибку, если не найден.
-func (s *InMemoryStore) GetShortURL(ctx context.Context, originalURL string) (string, error) {
-	for k, v := range s.db {
-		if v.OriginalURL == originalURL {
-			return k, nil
+// GetShortURL возвращает запись по исходному URL или ошибку, если она не найдена.
+// Если userID не пуст, поиск ведётся только среди ссылок этого пользователя.
+func (s *InMemoryStore) GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, v := range s.db {
+		if v.OriginalURL == originalURL && !v.DeletedFlag && (userID == "" || v.UserID == userID && v.TeamID == "") {
+			return v, nil
 		}
 	}
 
-	return "", ErrShortURLNotFound
+	return models.URLRecord{}, ErrShortURLNotFound
 }
 
 // Ready сообщает о готовности хранилища.
@@ -52,6 +90,11 @@ func (s *InMemoryStore) Ready() bool {
 	return true
 }
 
+// Ping проверяет доступность хранилища; хранилище в памяти доступно всегда.
+func (s *InMemoryStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
 // SaveBatch сохраняет набор записей.
 func (s *InMemoryStore) SaveBatch(records []models.URLRecord) error {
 	var err error
@@ -63,16 +106,18 @@ func (s *InMemoryStore) SaveBatch(records []models.URLRecord) error {
 
 // GetUserURLs возвращает ссылки пользователя.
 func (s *InMemoryStore) GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	count := 0
 	for _, record := range s.db {
-		if record.UserID == userID && !record.DeletedFlag {
+		if record.UserID == userID && record.TeamID == "" && !record.DeletedFlag {
 			count++
 		}
 	}
 
 	urls := make([]models.UserURLsResponse, 0, count)
 	for _, record := range s.db {
-		if record.UserID == userID && !record.DeletedFlag {
+		if record.UserID == userID && record.TeamID == "" && !record.DeletedFlag {
 			urls = append(urls, models.UserURLsResponse{
 				ShortURL:    record.ShortURL,
 				OriginalURL: record.OriginalURL,
@@ -84,9 +129,11 @@ func (s *InMemoryStore) GetUserURLs(ctx context.Context, userID string) ([]model
 
 // DeleteUserURLs помечает как удалённые ссылки пользователя.
 func (s *InMemoryStore) DeleteUserURLs(ctx context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, id := range ids {
 		record, ok := s.db[id]
-		if ok && record.UserID == userID && !record.DeletedFlag {
+		if ok && record.UserID == userID && record.TeamID == "" && !record.DeletedFlag {
 			record.DeletedFlag = true
 			s.db[id] = record
 		}
@@ -94,6 +141,441 @@ func (s *InMemoryStore) DeleteUserURLs(ctx context.Context, userID string, ids [
 	return nil
 }
 
+// SaveAPIKey сохраняет API-ключ.
+func (s *InMemoryStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKeys[key.Hash] = key
+	return nil
+}
+
+// GetAPIKeyByHash возвращает API-ключ по его хэшу.
+func (s *InMemoryStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, found := s.apiKeys[hash]
+	if !found {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys возвращает API-ключи пользователя.
+func (s *InMemoryStore) GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []models.APIKey
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// DeleteUserAPIKeys удаляет API-ключи пользователя с указанными идентификаторами.
+func (s *InMemoryStore) DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, key := range s.apiKeys {
+		if key.UserID == userID && slices.Contains(ids, key.ID) {
+			delete(s.apiKeys, hash)
+		}
+	}
+	return nil
+}
+
+// CreateUser сохраняет новую учётную запись.
+// Возвращает ErrUserExists, если email уже занят.
+func (s *InMemoryStore) CreateUser(ctx context.Context, user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.users[user.Email]; found {
+		return ErrUserExists
+	}
+	s.users[user.Email] = user
+	return nil
+}
+
+// GetUserByEmail возвращает учётную запись по email.
+func (s *InMemoryStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, found := s.users[email]
+	if !found {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// SaveSession сохраняет сессию.
+func (s *InMemoryStore) SaveSession(ctx context.Context, session models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.Hash] = session
+	return nil
+}
+
+// GetSession возвращает сессию по хэшу токена.
+func (s *InMemoryStore) GetSession(ctx context.Context, hash string) (models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, found := s.sessions[hash]
+	if !found {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// DeleteSession удаляет сессию.
+func (s *InMemoryStore) DeleteSession(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, hash)
+	return nil
+}
+
+// TransferUserURLs передаёт ссылки пользователя fromUserID пользователю toUserID.
+// Ссылки на URL, которые уже есть у toUserID, остаются у прежнего владельца.
+func (s *InMemoryStore) TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return transferURLs(s.db, fromUserID, toUserID), nil
+}
+
+// transferURLs меняет владельца ссылок в карте записей и возвращает число переданных ссылок.
+func transferURLs(db map[string]models.URLRecord, fromUserID string, toUserID string) int {
+	owned := make(map[string]bool)
+	for _, record := range db {
+		if record.UserID == toUserID && record.TeamID == "" && !record.DeletedFlag {
+			owned[record.OriginalURL] = true
+		}
+	}
+
+	count := 0
+	for key, record := range db {
+		if record.UserID != fromUserID || record.TeamID != "" || record.DeletedFlag || owned[record.OriginalURL] {
+			continue
+		}
+		record.UserID = toUserID
+		db[key] = record
+		owned[record.OriginalURL] = true
+		count++
+	}
+	return count
+}
+
+// SearchURLs возвращает ссылки всех пользователей, подходящие под фильтр.
+func (s *InMemoryStore) SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return searchURLs(s.db, filter), nil
+}
+
+// GetURL возвращает запись по короткому ключу.
+func (s *InMemoryStore) GetURL(ctx context.Context, shortURL string) (models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return models.URLRecord{}, ErrShortURLNotFound
+	}
+	return record, nil
+}
+
+// SetURLDisabled отключает или включает ссылку.
+func (s *InMemoryStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return ErrShortURLNotFound
+	}
+	record.Disabled = disabled
+	s.db[shortURL] = record
+	return nil
+}
+
+// SetURLOwner назначает ссылке нового владельца. Возвращает ErrOriginalURLExists,
+// если у нового владельца уже есть ссылка на тот же URL, а дедупликация
+// действует в пределах пользователя.
+func (s *InMemoryStore) SetURLOwner(ctx context.Context, shortURL string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, found := s.db[shortURL]
+	if !found {
+		return ErrShortURLNotFound
+	}
+	if _, found := findDuplicate(s.db, s.scope, record.OriginalURL, userID, record.TeamID, shortURL); found && !record.DeletedFlag {
+		return ErrOriginalURLExists
+	}
+	record.UserID = userID
+	s.db[shortURL] = record
+	return nil
+}
+
+// searchURLs отбирает записи по фильтру, упорядочивает их по короткому ключу
+// и возвращает запрошенную страницу.
+func searchURLs(db map[string]models.URLRecord, filter models.URLFilter) []models.URLRecord {
+	query := strings.ToLower(filter.Query)
+	var found []models.URLRecord
+	for _, record := range db {
+		if filter.UserID != "" && record.UserID != filter.UserID {
+			continue
+		}
+		if record.DeletedFlag && !filter.IncludeDeleted {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(record.ShortURL), query) &&
+			!strings.Contains(strings.ToLower(record.OriginalURL), query) {
+			continue
+		}
+		found = append(found, record)
+	}
+	slices.SortFunc(found, func(a, b models.URLRecord) int {
+		return strings.Compare(a.ShortURL, b.ShortURL)
+	})
+
+	if filter.Offset >= len(found) {
+		return nil
+	}
+	found = found[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(found) {
+		found = found[:filter.Limit]
+	}
+	return found
+}
+
+// SaveTeamURL сохраняет ссылку рабочего пространства teamID, созданную userID.
+func (s *InMemoryStore) SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, found := findDuplicate(s.db, s.scope, originalURL, userID, teamID, ""); found {
+		return &ExistingURLError{Record: existing}
+	}
+	s.db[shortURL] = models.URLRecord{
+		ShortURL:    shortURL,
+		OriginalURL: originalURL,
+		UserID:      userID,
+		TeamID:      teamID,
+	}
+	return nil
+}
+
+// GetTeamShortURL возвращает ссылку рабочего пространства по исходному URL.
+func (s *InMemoryStore) GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return teamShortURL(s.db, teamID, originalURL)
+}
+
+// GetTeamURLs возвращает неудалённые ссылки рабочего пространства.
+func (s *InMemoryStore) GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return teamURLs(s.db, teamID), nil
+}
+
+// UpdateTeamURL меняет исходный URL ссылки рабочего пространства.
+func (s *InMemoryStore) UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return updateTeamURL(s.db, s.scope, teamID, shortURL, originalURL)
+}
+
+// DeleteTeamURLs помечает как удалённые ссылки рабочего пространства.
+func (s *InMemoryStore) DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleteTeamURLs(s.db, teamID, ids)
+	return nil
+}
+
+// CreateTeam создаёт рабочее пространство вместе с его первым участником.
+func (s *InMemoryStore) CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams[team.ID] = team
+	s.members[team.ID] = map[string]models.TeamMember{owner.UserID: owner}
+	return nil
+}
+
+// GetTeam возвращает рабочее пространство по идентификатору.
+func (s *InMemoryStore) GetTeam(ctx context.Context, teamID string) (models.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	team, found := s.teams[teamID]
+	if !found {
+		return models.Team{}, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// GetUserTeams возвращает рабочие пространства пользователя с его ролями.
+func (s *InMemoryStore) GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return userTeams(s.teams, s.members, userID), nil
+}
+
+// GetTeamMember возвращает участие пользователя в рабочем пространстве.
+func (s *InMemoryStore) GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	member, found := s.members[teamID][userID]
+	if !found {
+		return models.TeamMember{}, ErrTeamMemberNotFound
+	}
+	return member, nil
+}
+
+// GetTeamMembers возвращает участников рабочего пространства.
+func (s *InMemoryStore) GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Collect(maps.Values(s.members[teamID])), nil
+}
+
+// SaveTeamMember добавляет участника или меняет его роль.
+func (s *InMemoryStore) SaveTeamMember(ctx context.Context, member models.TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.teams[member.TeamID]; !found {
+		return ErrTeamNotFound
+	}
+	s.members[member.TeamID][member.UserID] = member
+	return nil
+}
+
+// DeleteTeamMember исключает пользователя из рабочего пространства.
+func (s *InMemoryStore) DeleteTeamMember(ctx context.Context, teamID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.members[teamID], userID)
+	return nil
+}
+
+// SaveTeamInvite сохраняет приглашение.
+func (s *InMemoryStore) SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invites[invite.Hash] = invite
+	return nil
+}
+
+// GetTeamInvite возвращает приглашение по хэшу токена.
+func (s *InMemoryStore) GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	invite, found := s.invites[hash]
+	if !found {
+		return models.TeamInvite{}, ErrTeamInviteNotFound
+	}
+	return invite, nil
+}
+
+// DeleteTeamInvite удаляет приглашение.
+func (s *InMemoryStore) DeleteTeamInvite(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.invites, hash)
+	return nil
+}
+
+// findDuplicate ищет в карте записей неудалённую ссылку на originalURL,
+// с которой конфликтует новая ссылка пользователя userID (или рабочего
+// пространства teamID) в области дедупликации scope. Правила совпадают
+// с уникальными индексами PostgresStore. Запись с ключом skip не учитывается:
+// так проверяется изменение существующей ссылки.
+func findDuplicate(db map[string]models.URLRecord, scope models.DedupScope, originalURL string, userID string, teamID string, skip string) (models.URLRecord, bool) {
+	if scope == models.DedupNone {
+		return models.URLRecord{}, false
+	}
+	for _, record := range db {
+		if record.OriginalURL != originalURL || record.DeletedFlag || record.ShortURL == skip {
+			continue
+		}
+		switch {
+		case scope == models.DedupGlobal,
+			teamID == "" && record.TeamID == "" && record.UserID == userID,
+			teamID != "" && record.TeamID == teamID:
+			return record, true
+		}
+	}
+	return models.URLRecord{}, false
+}
+
+// teamShortURL ищет в карте записей ссылку рабочего пространства на originalURL.
+func teamShortURL(db map[string]models.URLRecord, teamID string, originalURL string) (models.URLRecord, error) {
+	for _, record := range db {
+		if record.TeamID == teamID && record.OriginalURL == originalURL && !record.DeletedFlag {
+			return record, nil
+		}
+	}
+	return models.URLRecord{}, ErrShortURLNotFound
+}
+
+// teamURLs возвращает неудалённые ссылки рабочего пространства, упорядоченные по ключу.
+func teamURLs(db map[string]models.URLRecord, teamID string) []models.URLRecord {
+	var urls []models.URLRecord
+	for _, record := range db {
+		if record.TeamID == teamID && !record.DeletedFlag {
+			urls = append(urls, record)
+		}
+	}
+	slices.SortFunc(urls, func(a, b models.URLRecord) int {
+		return strings.Compare(a.ShortURL, b.ShortURL)
+	})
+	return urls
+}
+
+// updateTeamURL меняет исходный URL неудалённой ссылки рабочего пространства.
+// Возвращает ErrOriginalURLExists, если изменённая ссылка совпала бы
+// с другой в области дедупликации scope.
+func updateTeamURL(db map[string]models.URLRecord, scope models.DedupScope, teamID string, shortURL string, originalURL string) error {
+	record, found := db[shortURL]
+	if !found || record.TeamID != teamID || record.DeletedFlag {
+		return ErrShortURLNotFound
+	}
+	if _, found := findDuplicate(db, scope, originalURL, "", teamID, shortURL); found {
+		return ErrOriginalURLExists
+	}
+	record.OriginalURL = originalURL
+	db[shortURL] = record
+	return nil
+}
+
+// deleteTeamURLs помечает удалёнными ссылки рабочего пространства и сообщает, изменилось ли что-то.
+func deleteTeamURLs(db map[string]models.URLRecord, teamID string, ids []string) bool {
+	changed := false
+	for _, id := range ids {
+		record, found := db[id]
+		if found && record.TeamID == teamID && !record.DeletedFlag {
+			record.DeletedFlag = true
+			db[id] = record
+			changed = true
+		}
+	}
+	return changed
+}
+
+// userTeams собирает рабочие пространства пользователя, упорядоченные по названию.
+func userTeams(teams map[string]models.Team, members map[string]map[string]models.TeamMember, userID string) []models.TeamResponse {
+	var resp []models.TeamResponse
+	for teamID, teamMembers := range members {
+		member, found := teamMembers[userID]
+		if !found {
+			continue
+		}
+		team := teams[teamID]
+		resp = append(resp, models.TeamResponse{ID: team.ID, Name: team.Name, Role: member.Role})
+	}
+	slices.SortFunc(resp, func(a, b models.TeamResponse) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return resp
+}
+
 // Close закрывает in-memory хранилище (ничего не делает).
 func (s *InMemoryStore) Close() error {
 	return nil
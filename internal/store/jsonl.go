@@ -0,0 +1,85 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// jsonlFile — вспомогательный файл в формате JSONL, в котором файловое
+// хранилище держит дополнительные сущности (API-ключи и т. п.).
+type jsonlFile[T any] struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// openJSONL открывает/создаёт файл и передаёт каждую загруженную запись в load.
+func openJSONL[T any](path string, load func(T)) (*jsonlFile[T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var item T
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			file.Close()
+			return nil, err
+		}
+		load(item)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &jsonlFile[T]{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// Append дописывает запись в конец файла.
+func (f *jsonlFile[T]) Append(item T) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if _, err := f.writer.Write(data); err != nil {
+		return err
+	}
+	if err := f.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	return f.writer.Flush()
+}
+
+// Rewrite перезаписывает файл переданным набором записей.
+func (f *jsonlFile[T]) Rewrite(items []T) error {
+	if err := f.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.file.Seek(0, 0); err != nil {
+		return err
+	}
+	f.writer.Reset(f.file)
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		f.writer.Write(data)
+		f.writer.WriteByte('\n')
+	}
+	return f.writer.Flush()
+}
+
+// Close сбрасывает буфер и закрывает файл. Вызов на nil ничего не делает,
+// что упрощает закрытие частично открытого хранилища.
+func (f *jsonlFile[T]) Close() error {
+	if f == nil {
+		return nil
+	}
+	if err := f.writer.Flush(); err != nil {
+		return err
+	}
+	return f.file.Close()
+}
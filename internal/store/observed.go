@@ -0,0 +1,364 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+)
+
+// Hook вызывается перед каждой операцией хранилища и возвращает контекст,
+// с которым выполняется операция, и функцию, вызываемую по её завершении.
+// В done передаётся ошибка, только если операция завершилась сбоем:
+// ожидаемые ответы вроде ErrShortURLNotFound сбоем не считаются.
+type Hook func(ctx context.Context, op string) (context.Context, func(failure error))
+
+// errNotReady передаётся в hook, если Ready вернул false.
+var errNotReady = errors.New("store is not ready")
+
+// ObservedStore оборачивает хранилище и вызывает хуки вокруг каждого его метода.
+// Используется для метрик и трассировки операций хранилища.
+type ObservedStore struct {
+	next  Store
+	hooks []Hook
+}
+
+// NewObservedStore оборачивает хранилище next хуками hooks. Хуки вызываются
+// в порядке передачи, а их функции завершения — в обратном.
+func NewObservedStore(next Store, hooks ...Hook) *ObservedStore {
+	return &ObservedStore{next: next, hooks: hooks}
+}
+
+// start вызывает хуки операции op и возвращает общую функцию завершения.
+func (s *ObservedStore) start(ctx context.Context, op string) (context.Context, func(err error)) {
+	dones := make([]func(error), len(s.hooks))
+	for i, hook := range s.hooks {
+		ctx, dones[i] = hook(ctx, op)
+	}
+	return ctx, func(err error) {
+		if !isFailure(err) {
+			err = nil
+		}
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
+
+// isFailure отличает сбои хранилища от ожидаемых ответов.
+func isFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrShortURLNotFound),
+		errors.Is(err, ErrOriginalURLExists),
+		errors.Is(err, ErrAPIKeyNotFound),
+		errors.Is(err, ErrUserNotFound),
+		errors.Is(err, ErrUserExists),
+		errors.Is(err, ErrSessionNotFound),
+		errors.Is(err, ErrTeamNotFound),
+		errors.Is(err, ErrTeamMemberNotFound),
+		errors.Is(err, ErrTeamInviteNotFound):
+		return false
+	}
+	return true
+}
+
+// Save вызывает одноимённый метод хранилища.
+func (s *ObservedStore) Save(ctx context.Context, originalURL string, shortURL string, userID string) error {
+	ctx, done := s.start(ctx, "Save")
+	err := s.next.Save(ctx, originalURL, shortURL, userID)
+	done(err)
+	return err
+}
+
+// GetOriginalURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetOriginalURL(ctx context.Context, shortURL string) (models.UserURLsResponse, bool) {
+	ctx, done := s.start(ctx, "GetOriginalURL")
+	res, found := s.next.GetOriginalURL(ctx, shortURL)
+	done(nil)
+	return res, found
+}
+
+// Ready вызывает одноимённый метод хранилища.
+func (s *ObservedStore) Ready() bool {
+	_, done := s.start(context.Background(), "Ready")
+	ready := s.next.Ready()
+	var err error
+	if !ready {
+		err = errNotReady
+	}
+	done(err)
+	return ready
+}
+
+// Ping вызывает одноимённый метод хранилища.
+func (s *ObservedStore) Ping(ctx context.Context) error {
+	ctx, done := s.start(ctx, "Ping")
+	err := s.next.Ping(ctx)
+	done(err)
+	return err
+}
+
+// GetShortURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetShortURL(ctx context.Context, originalURL string, userID string) (models.URLRecord, error) {
+	ctx, done := s.start(ctx, "GetShortURL")
+	res, err := s.next.GetShortURL(ctx, originalURL, userID)
+	done(err)
+	return res, err
+}
+
+// GetUserURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetUserURLs(ctx context.Context, userID string) ([]models.UserURLsResponse, error) {
+	ctx, done := s.start(ctx, "GetUserURLs")
+	res, err := s.next.GetUserURLs(ctx, userID)
+	done(err)
+	return res, err
+}
+
+// DeleteUserURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteUserURLs(ctx context.Context, userID string, ids []string) error {
+	ctx, done := s.start(ctx, "DeleteUserURLs")
+	err := s.next.DeleteUserURLs(ctx, userID, ids)
+	done(err)
+	return err
+}
+
+// SaveAPIKey вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	ctx, done := s.start(ctx, "SaveAPIKey")
+	err := s.next.SaveAPIKey(ctx, key)
+	done(err)
+	return err
+}
+
+// GetAPIKeyByHash вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	ctx, done := s.start(ctx, "GetAPIKeyByHash")
+	res, err := s.next.GetAPIKeyByHash(ctx, hash)
+	done(err)
+	return res, err
+}
+
+// GetUserAPIKeys вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetUserAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	ctx, done := s.start(ctx, "GetUserAPIKeys")
+	res, err := s.next.GetUserAPIKeys(ctx, userID)
+	done(err)
+	return res, err
+}
+
+// DeleteUserAPIKeys вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteUserAPIKeys(ctx context.Context, userID string, ids []string) error {
+	ctx, done := s.start(ctx, "DeleteUserAPIKeys")
+	err := s.next.DeleteUserAPIKeys(ctx, userID, ids)
+	done(err)
+	return err
+}
+
+// CreateUser вызывает одноимённый метод хранилища.
+func (s *ObservedStore) CreateUser(ctx context.Context, user models.User) error {
+	ctx, done := s.start(ctx, "CreateUser")
+	err := s.next.CreateUser(ctx, user)
+	done(err)
+	return err
+}
+
+// GetUserByEmail вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	ctx, done := s.start(ctx, "GetUserByEmail")
+	res, err := s.next.GetUserByEmail(ctx, email)
+	done(err)
+	return res, err
+}
+
+// SaveSession вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SaveSession(ctx context.Context, session models.Session) error {
+	ctx, done := s.start(ctx, "SaveSession")
+	err := s.next.SaveSession(ctx, session)
+	done(err)
+	return err
+}
+
+// GetSession вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetSession(ctx context.Context, hash string) (models.Session, error) {
+	ctx, done := s.start(ctx, "GetSession")
+	res, err := s.next.GetSession(ctx, hash)
+	done(err)
+	return res, err
+}
+
+// DeleteSession вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteSession(ctx context.Context, hash string) error {
+	ctx, done := s.start(ctx, "DeleteSession")
+	err := s.next.DeleteSession(ctx, hash)
+	done(err)
+	return err
+}
+
+// TransferUserURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) TransferUserURLs(ctx context.Context, fromUserID string, toUserID string) (int, error) {
+	ctx, done := s.start(ctx, "TransferUserURLs")
+	res, err := s.next.TransferUserURLs(ctx, fromUserID, toUserID)
+	done(err)
+	return res, err
+}
+
+// SearchURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SearchURLs(ctx context.Context, filter models.URLFilter) ([]models.URLRecord, error) {
+	ctx, done := s.start(ctx, "SearchURLs")
+	res, err := s.next.SearchURLs(ctx, filter)
+	done(err)
+	return res, err
+}
+
+// GetURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetURL(ctx context.Context, shortURL string) (models.URLRecord, error) {
+	ctx, done := s.start(ctx, "GetURL")
+	res, err := s.next.GetURL(ctx, shortURL)
+	done(err)
+	return res, err
+}
+
+// SetURLDisabled вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	ctx, done := s.start(ctx, "SetURLDisabled")
+	err := s.next.SetURLDisabled(ctx, shortURL, disabled)
+	done(err)
+	return err
+}
+
+// SetURLOwner вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SetURLOwner(ctx context.Context, shortURL string, userID string) error {
+	ctx, done := s.start(ctx, "SetURLOwner")
+	err := s.next.SetURLOwner(ctx, shortURL, userID)
+	done(err)
+	return err
+}
+
+// SaveTeamURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SaveTeamURL(ctx context.Context, teamID string, originalURL string, shortURL string, userID string) error {
+	ctx, done := s.start(ctx, "SaveTeamURL")
+	err := s.next.SaveTeamURL(ctx, teamID, originalURL, shortURL, userID)
+	done(err)
+	return err
+}
+
+// GetTeamShortURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeamShortURL(ctx context.Context, teamID string, originalURL string) (models.URLRecord, error) {
+	ctx, done := s.start(ctx, "GetTeamShortURL")
+	res, err := s.next.GetTeamShortURL(ctx, teamID, originalURL)
+	done(err)
+	return res, err
+}
+
+// GetTeamURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeamURLs(ctx context.Context, teamID string) ([]models.URLRecord, error) {
+	ctx, done := s.start(ctx, "GetTeamURLs")
+	res, err := s.next.GetTeamURLs(ctx, teamID)
+	done(err)
+	return res, err
+}
+
+// UpdateTeamURL вызывает одноимённый метод хранилища.
+func (s *ObservedStore) UpdateTeamURL(ctx context.Context, teamID string, shortURL string, originalURL string) error {
+	ctx, done := s.start(ctx, "UpdateTeamURL")
+	err := s.next.UpdateTeamURL(ctx, teamID, shortURL, originalURL)
+	done(err)
+	return err
+}
+
+// DeleteTeamURLs вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteTeamURLs(ctx context.Context, teamID string, ids []string) error {
+	ctx, done := s.start(ctx, "DeleteTeamURLs")
+	err := s.next.DeleteTeamURLs(ctx, teamID, ids)
+	done(err)
+	return err
+}
+
+// CreateTeam вызывает одноимённый метод хранилища.
+func (s *ObservedStore) CreateTeam(ctx context.Context, team models.Team, owner models.TeamMember) error {
+	ctx, done := s.start(ctx, "CreateTeam")
+	err := s.next.CreateTeam(ctx, team, owner)
+	done(err)
+	return err
+}
+
+// GetTeam вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeam(ctx context.Context, teamID string) (models.Team, error) {
+	ctx, done := s.start(ctx, "GetTeam")
+	res, err := s.next.GetTeam(ctx, teamID)
+	done(err)
+	return res, err
+}
+
+// GetUserTeams вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetUserTeams(ctx context.Context, userID string) ([]models.TeamResponse, error) {
+	ctx, done := s.start(ctx, "GetUserTeams")
+	res, err := s.next.GetUserTeams(ctx, userID)
+	done(err)
+	return res, err
+}
+
+// GetTeamMember вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeamMember(ctx context.Context, teamID string, userID string) (models.TeamMember, error) {
+	ctx, done := s.start(ctx, "GetTeamMember")
+	res, err := s.next.GetTeamMember(ctx, teamID, userID)
+	done(err)
+	return res, err
+}
+
+// GetTeamMembers вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeamMembers(ctx context.Context, teamID string) ([]models.TeamMember, error) {
+	ctx, done := s.start(ctx, "GetTeamMembers")
+	res, err := s.next.GetTeamMembers(ctx, teamID)
+	done(err)
+	return res, err
+}
+
+// SaveTeamMember вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SaveTeamMember(ctx context.Context, member models.TeamMember) error {
+	ctx, done := s.start(ctx, "SaveTeamMember")
+	err := s.next.SaveTeamMember(ctx, member)
+	done(err)
+	return err
+}
+
+// DeleteTeamMember вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteTeamMember(ctx context.Context, teamID string, userID string) error {
+	ctx, done := s.start(ctx, "DeleteTeamMember")
+	err := s.next.DeleteTeamMember(ctx, teamID, userID)
+	done(err)
+	return err
+}
+
+// SaveTeamInvite вызывает одноимённый метод хранилища.
+func (s *ObservedStore) SaveTeamInvite(ctx context.Context, invite models.TeamInvite) error {
+	ctx, done := s.start(ctx, "SaveTeamInvite")
+	err := s.next.SaveTeamInvite(ctx, invite)
+	done(err)
+	return err
+}
+
+// GetTeamInvite вызывает одноимённый метод хранилища.
+func (s *ObservedStore) GetTeamInvite(ctx context.Context, hash string) (models.TeamInvite, error) {
+	ctx, done := s.start(ctx, "GetTeamInvite")
+	res, err := s.next.GetTeamInvite(ctx, hash)
+	done(err)
+	return res, err
+}
+
+// DeleteTeamInvite вызывает одноимённый метод хранилища.
+func (s *ObservedStore) DeleteTeamInvite(ctx context.Context, hash string) error {
+	ctx, done := s.start(ctx, "DeleteTeamInvite")
+	err := s.next.DeleteTeamInvite(ctx, hash)
+	done(err)
+	return err
+}
+
+// Close вызывает одноимённый метод хранилища.
+func (s *ObservedStore) Close() error {
+	_, done := s.start(context.Background(), "Close")
+	err := s.next.Close()
+	done(err)
+	return err
+}
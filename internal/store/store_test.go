@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlexeySalamakhin/URLShortener/internal/models"
+)
+
+// testStores возвращает хранилища в памяти и в файле с областью дедупликации scope.
+func testStores(t *testing.T, scope models.DedupScope) map[string]Store {
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "urls.jsonl"), scope)
+	require.NoError(t, err)
+	t.Cleanup(func() { fileStore.Close() })
+	return map[string]Store{
+		"memory": NewInMemoryStore(scope),
+		"file":   fileStore,
+	}
+}
+
+func TestConcurrentSaveDedup(t *testing.T) {
+	const workers = 50
+	ctx := context.Background()
+
+	for name, s := range testStores(t, models.DedupGlobal) {
+		t.Run(name, func(t *testing.T) {
+			errs := make([]error, workers)
+			var wg sync.WaitGroup
+			for i := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = s.Save(ctx, "https://example.com/race", fmt.Sprintf("key%d", i), fmt.Sprintf("user%d", i))
+				}()
+			}
+			wg.Wait()
+
+			winner := ""
+			for i, err := range errs {
+				if err == nil {
+					require.Empty(t, winner, "only one save may succeed")
+					winner = fmt.Sprintf("key%d", i)
+				}
+			}
+			require.NotEmpty(t, winner)
+			for _, err := range errs {
+				if err == nil {
+					continue
+				}
+				require.ErrorIs(t, err, ErrOriginalURLExists)
+				var existing *ExistingURLError
+				require.True(t, errors.As(err, &existing))
+				assert.Equal(t, winner, existing.Record.ShortURL)
+			}
+		})
+	}
+}
+
+func TestSaveDedupScopes(t *testing.T) {
+	ctx := context.Background()
+
+	for name, s := range testStores(t, models.DedupUser) {
+		t.Run(name+"/user", func(t *testing.T) {
+			require.NoError(t, s.Save(ctx, "https://example.com", "a1", "alice"))
+			assert.ErrorIs(t, s.Save(ctx, "https://example.com", "a2", "alice"), ErrOriginalURLExists)
+			require.NoError(t, s.Save(ctx, "https://example.com", "b1", "bob"))
+			require.NoError(t, s.SaveTeamURL(ctx, "team", "https://example.com", "t1", "alice"))
+			assert.ErrorIs(t, s.SaveTeamURL(ctx, "team", "https://example.com", "t2", "bob"), ErrOriginalURLExists)
+
+			// удалённую ссылку можно сократить заново
+			require.NoError(t, s.DeleteUserURLs(ctx, "alice", []string{"a1"}))
+			assert.NoError(t, s.Save(ctx, "https://example.com", "a3", "alice"))
+		})
+	}
+
+	for name, s := range testStores(t, models.DedupNone) {
+		t.Run(name+"/none", func(t *testing.T) {
+			require.NoError(t, s.Save(ctx, "https://example.com", "n1", "alice"))
+			assert.NoError(t, s.Save(ctx, "https://example.com", "n2", "alice"))
+		})
+	}
+}
+
+func TestUpdateTeamURLDedup(t *testing.T) {
+	ctx := context.Background()
+
+	for _, scope := range []models.DedupScope{models.DedupUser, models.DedupGlobal} {
+		for name, s := range testStores(t, scope) {
+			t.Run(fmt.Sprintf("%s/%s", name, scope), func(t *testing.T) {
+				require.NoError(t, s.SaveTeamURL(ctx, "team", "https://example.com/a", "t1", "alice"))
+				require.NoError(t, s.SaveTeamURL(ctx, "team", "https://example.com/b", "t2", "bob"))
+				assert.ErrorIs(t, s.UpdateTeamURL(ctx, "team", "t2", "https://example.com/a"), ErrOriginalURLExists)
+				// ссылка не конфликтует сама с собой
+				assert.NoError(t, s.UpdateTeamURL(ctx, "team", "t1", "https://example.com/a"))
+
+				// из одновременных правок на один URL проходит только одна
+				errs := make([]error, 2)
+				var wg sync.WaitGroup
+				for i, key := range []string{"t1", "t2"} {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						errs[i] = s.UpdateTeamURL(ctx, "team", key, "https://example.com/c")
+					}()
+				}
+				wg.Wait()
+				succeeded := 0
+				for _, err := range errs {
+					if err == nil {
+						succeeded++
+						continue
+					}
+					assert.ErrorIs(t, err, ErrOriginalURLExists)
+				}
+				assert.Equal(t, 1, succeeded)
+			})
+		}
+	}
+}
+
+func TestSetURLOwnerDedup(t *testing.T) {
+	ctx := context.Background()
+
+	for name, s := range testStores(t, models.DedupUser) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, s.Save(ctx, "https://example.com", "a1", "alice"))
+			require.NoError(t, s.Save(ctx, "https://example.com", "b1", "bob"))
+			assert.ErrorIs(t, s.SetURLOwner(ctx, "a1", "bob"), ErrOriginalURLExists)
+			assert.NoError(t, s.SetURLOwner(ctx, "a1", "alice"))
+
+			// у ссылок рабочих пространств автор на дедупликацию не влияет
+			require.NoError(t, s.SaveTeamURL(ctx, "team", "https://example.com", "t1", "alice"))
+			assert.NoError(t, s.SetURLOwner(ctx, "t1", "bob"))
+
+			// после удаления ссылки Боба владельца можно сменить
+			require.NoError(t, s.DeleteUserURLs(ctx, "bob", []string{"b1"}))
+			assert.NoError(t, s.SetURLOwner(ctx, "a1", "bob"))
+		})
+	}
+}
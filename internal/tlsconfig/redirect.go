@@ -0,0 +1,31 @@
+package tlsconfig
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RedirectHandler перенаправляет запросы по HTTP на тот же адрес по HTTPS.
+// httpsAddr — адрес HTTPS-сервера; его порт, если он не 443, добавляется к
+// имени хоста из запроса. GET и HEAD получают 301, остальные методы — 308,
+// чтобы клиент повторил запрос с тем же методом и телом.
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, target, code)
+	})
+}
@@ -0,0 +1,267 @@
+// Package tlsconfig собирает настройки TLS сервера: сертификат из файлов с
+// перечитыванием при их изменении, проверку клиентских сертификатов (mTLS),
+// версии протокола и наборы шифров.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	logger "github.com/AlexeySalamakhin/URLShortener/internal/logger"
+)
+
+// Режимы проверки клиентских сертификатов.
+const (
+	ClientAuthNone          = "none"
+	ClientAuthRequest       = "request"
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	ClientAuthRequire       = "require"
+)
+
+// Options — параметры TLS сервера.
+type Options struct {
+	// CertFile и KeyFile — PEM-файлы сертификата (с цепочкой) и ключа.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile — PEM-файл удостоверяющих центров клиентских сертификатов.
+	ClientCAFile string
+	// ClientAuth — режим проверки клиентских сертификатов; пустое значение
+	// означает require при заданном ClientCAFile и none без него.
+	ClientAuth string
+	// MinVersion и MaxVersion — границы версий протокола: "1.2" или "1.3".
+	// Пустые значения означают 1.2 и последнюю поддерживаемую версию.
+	MinVersion string
+	MaxVersion string
+	// CipherSuites — имена наборов шифров для TLS 1.2 (в TLS 1.3 они не
+	// настраиваются); пустой список означает наборы Go по умолчанию.
+	CipherSuites []string
+}
+
+// ParseVersion разбирает версию протокола вида "1.2". Пустая строка
+// возвращает 0.
+func ParseVersion(s string) (uint16, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return 0, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q: expected 1.2 or 1.3", s)
+	}
+}
+
+// ParseCipherSuites разбирает имена наборов шифров, например
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Небезопасные наборы не принимаются.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseClientAuth разбирает режим проверки клиентских сертификатов.
+func ParseClientAuth(s string, hasCA bool) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		if hasCA {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		if !hasCA {
+			return 0, errors.New("client auth verify-if-given requires a client CA file")
+		}
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		if !hasCA {
+			return 0, errors.New("client auth require requires a client CA file")
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unknown client auth mode %q: expected none, request, verify-if-given or require", s)
+	}
+}
+
+// Apply записывает в cfg версии, наборы шифров и режим проверки клиентов из
+// opts. Сертификат сервера Apply не трогает.
+func (opts Options) Apply(cfg *tls.Config) error {
+	var err error
+	if cfg.MinVersion, err = ParseVersion(opts.MinVersion); err != nil {
+		return err
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if cfg.MaxVersion, err = ParseVersion(opts.MaxVersion); err != nil {
+		return err
+	}
+	if cfg.MaxVersion != 0 && cfg.MaxVersion < cfg.MinVersion {
+		return errors.New("max TLS version is lower than min TLS version")
+	}
+	if cfg.CipherSuites, err = ParseCipherSuites(opts.CipherSuites); err != nil {
+		return err
+	}
+	if cfg.ClientAuth, err = ParseClientAuth(opts.ClientAuth, opts.ClientCAFile != ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reloader хранит сертификат сервера и пул клиентских УЦ, загруженные из
+// файлов, и перечитывает их, когда файлы меняются. Если новые файлы
+// некорректны, продолжают использоваться прежние.
+type Reloader struct {
+	opts      Options
+	cert      atomic.Pointer[tls.Certificate]
+	clientCAs atomic.Pointer[x509.CertPool]
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+}
+
+// NewReloader загружает файлы из opts. CertFile и KeyFile обязательны,
+// ClientCAFile — нет.
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("both certificate and key files are required")
+	}
+	r := &Reloader{opts: opts}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает сертификат и пул клиентских УЦ.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		if pool, err = LoadCertPool(r.opts.ClientCAFile); err != nil {
+			return err
+		}
+	}
+	r.cert.Store(&cert)
+	r.clientCAs.Store(pool)
+	r.modTimes = r.stat()
+	return nil
+}
+
+// LoadCertPool читает PEM-файл с сертификатами удостоверяющих центров.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("load CA bundle: no certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// TLSConfig возвращает настройки сервера, которые при каждом рукопожатии
+// берут актуальные сертификат и пул клиентских УЦ.
+func (r *Reloader) TLSConfig() (*tls.Config, error) {
+	base := &tls.Config{}
+	if err := r.opts.Apply(base); err != nil {
+		return nil, err
+	}
+	// http.Server добавляет h2 только в собственную копию настроек, а
+	// GetConfigForClient её подменяет
+	base.NextProtos = []string{"h2", "http/1.1"}
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.Certificates = []tls.Certificate{*r.cert.Load()}
+		c.ClientCAs = r.clientCAs.Load()
+		return c, nil
+	}
+	// GetCertificate нужен, чтобы http.Server не требовал файлы в ServeTLS
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.cert.Load(), nil
+	}
+	return cfg, nil
+}
+
+// Watch проверяет время изменения файлов раз в interval и перечитывает их при
+// изменении, пока не отменён ctx.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				// файлы могут быть записаны не полностью — попробуем на следующем тике
+				logger.Log.Error("Failed to reload TLS certificate", zap.Error(err))
+				continue
+			}
+			logger.Log.Info("TLS certificate reloaded", zap.String("cert_file", r.opts.CertFile))
+		}
+	}
+}
+
+// changed сообщает, изменились ли файлы с последней успешной загрузки.
+func (r *Reloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for path, modTime := range r.stat() {
+		if !modTime.Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) stat() map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, path := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			times[path] = info.ModTime()
+		}
+	}
+	return times
+}
@@ -0,0 +1,181 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA — удостоверяющий центр для выпуска тестовых сертификатов.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат с именем cn и возвращает его и ключ в PEM.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestReloaderMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCA, clientCA := newTestCA(t), newTestCA(t)
+	opts := Options{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "clients.pem"),
+		MinVersion:   "1.2",
+	}
+	certPEM, keyPEM := serverCA.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeFile(t, opts.CertFile, certPEM)
+	writeFile(t, opts.KeyFile, keyPEM)
+	writeFile(t, opts.ClientCAFile, clientCA.pem)
+
+	reloader, err := NewReloader(opts)
+	require.NoError(t, err)
+	tlsCfg, err := reloader.TLSConfig()
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = tlsCfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(serverCA.pem)
+	clientCertPEM, clientKeyPEM := clientCA.issue(t, "internal-caller", x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	require.NoError(t, err)
+
+	get := func(certs ...tls.Certificate) (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	_, err = get()
+	assert.Error(t, err, "client certificate is required")
+
+	serverName, err := get(clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", serverName)
+
+	// новый сертификат подхватывается без перезапуска
+	certPEM, keyPEM = serverCA.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, opts.CertFile, certPEM)
+	writeFile(t, opts.KeyFile, keyPEM)
+	require.NoError(t, reloader.Reload())
+	serverName, err = get(clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, "server-2", serverName)
+
+	// повреждённый файл не заменяет рабочий сертификат
+	writeFile(t, opts.KeyFile, []byte("garbage"))
+	assert.Error(t, reloader.Reload())
+	serverName, err = get(clientCert)
+	require.NoError(t, err)
+	assert.Equal(t, "server-2", serverName)
+}
+
+func TestApply(t *testing.T) {
+	cfg := &tls.Config{}
+	require.NoError(t, Options{
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	}.Apply(cfg))
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+
+	assert.Error(t, Options{MinVersion: "1.0"}.Apply(&tls.Config{}))
+	assert.Error(t, Options{MinVersion: "1.3", MaxVersion: "1.2"}.Apply(&tls.Config{}))
+	assert.Error(t, Options{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}.Apply(&tls.Config{}))
+	assert.Error(t, Options{ClientAuth: ClientAuthRequire}.Apply(&tls.Config{}))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		addr, method, host, target string
+		code                       int
+	}{
+		{":443", http.MethodGet, "sho.rt", "https://sho.rt/abc?x=1", http.StatusMovedPermanently},
+		{":8443", http.MethodGet, "sho.rt:8080", "https://sho.rt:8443/abc?x=1", http.StatusMovedPermanently},
+		{":8443", http.MethodPost, "[::1]:8080", "https://[::1]:8443/abc?x=1", http.StatusPermanentRedirect},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "http://"+tt.host+"/abc?x=1", nil)
+		w := httptest.NewRecorder()
+		RedirectHandler(tt.addr).ServeHTTP(w, r)
+		assert.Equal(t, tt.code, w.Code)
+		assert.Equal(t, tt.target, w.Header().Get("Location"))
+	}
+}
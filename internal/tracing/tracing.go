@@ -0,0 +1,130 @@
+// Package tracing настраивает трассировку OpenTelemetry: экспорт спанов,
+// распространение контекста W3C traceparent и спаны операций хранилища.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName — имя, под которым сервис создаёт свои спаны.
+const InstrumentationName = "github.com/AlexeySalamakhin/URLShortener"
+
+// Поддерживаемые экспортёры спанов.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config — параметры трассировки.
+type Config struct {
+	// Exporter — куда отправлять спаны: none, stdout или otlp.
+	Exporter string
+	// OTLPEndpoint — адрес коллектора OTLP/HTTP (host:port); пустой адрес
+	// означает значение по умолчанию или OTEL_EXPORTER_OTLP_ENDPOINT.
+	OTLPEndpoint string
+	// OTLPInsecure — отправлять спаны в коллектор по HTTP без TLS.
+	OTLPInsecure bool
+	// SampleRatio — доля трассируемых запросов от 0 до 1. Если у входящего
+	// запроса есть родительский спан, решение о записи берётся из него.
+	SampleRatio float64
+	// ServiceName — имя сервиса в ресурсе спанов.
+	ServiceName string
+	// Output — поток для экспортёра stdout; по умолчанию os.Stdout.
+	Output io.Writer
+}
+
+// Setup создаёт поставщик трассировки по настройкам cfg и делает его
+// глобальным вместе с пропагатором W3C traceparent/baggage. Возвращаемая
+// функция выгружает накопленные спаны и останавливает поставщик.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		out := cfg.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), cfg.ServiceName, cfg.SampleRatio)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider создаёт поставщик трассировки с процессором spans. В тестах
+// вместе с tracetest.NewInMemoryExporter и sdktrace.NewSimpleSpanProcessor
+// позволяет проверять записанные спаны.
+func NewProvider(processor sdktrace.SpanProcessor, serviceName string, sampleRatio float64) *sdktrace.TracerProvider {
+	if serviceName == "" {
+		serviceName = "shortener"
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+}
+
+// Tracer возвращает трассировщик сервиса из глобального поставщика.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start начинает дочерний спан name в контексте ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End завершает спан, отмечая его ошибкой, если err не nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StoreHook — хук store.ObservedStore, создающий спан на каждую операцию хранилища.
+func StoreHook(ctx context.Context, op string) (context.Context, func(failure error)) {
+	ctx, span := Start(ctx, "store."+op, attribute.String("store.operation", op))
+	return ctx, func(failure error) {
+		End(span, failure)
+	}
+}